@@ -0,0 +1,147 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+)
+
+// minBackfillWindow is the smallest window a backfill window is split into.
+const minBackfillWindow = time.Minute
+
+// TotalCountRelationEqual is the relation of a total count that is exact. Any
+// other relation means the count is a lower bound.
+const TotalCountRelationEqual = "eq"
+
+type BackfillConfig struct {
+	// Enabled walks the time range from Start to End in windows before
+	// continuing with regular polling from End.
+	Enabled bool `json:"enabled" default:"false"`
+	// Start is the RFC3339 timestamp at which the backfill starts.
+	Start string `json:"start"`
+	// End is the RFC3339 timestamp at which the backfill ends (exclusive). It
+	// defaults to the time the connector is opened.
+	End string `json:"end"`
+	// Window is the time range queried at once.
+	Window time.Duration `json:"window" default:"24h"`
+	// MaxCount is the total count above which a window is split in half.
+	MaxCount int `json:"maxCount" default:"10000"`
+}
+
+type backfill struct {
+	start    time.Time
+	end      time.Time
+	window   time.Duration
+	maxCount int
+}
+
+func newBackfill(cfg BackfillConfig, now time.Time) (backfill, error) {
+	if cfg.Start == "" {
+		return backfill{}, errors.New("backfill start is required")
+	}
+	start, err := time.Parse(time.RFC3339, cfg.Start)
+	if err != nil {
+		return backfill{}, fmt.Errorf("invalid backfill start: %w", err)
+	}
+
+	end := now
+	if cfg.End != "" {
+		end, err = time.Parse(time.RFC3339, cfg.End)
+		if err != nil {
+			return backfill{}, fmt.Errorf("invalid backfill end: %w", err)
+		}
+	}
+	if !start.Before(end) {
+		return backfill{}, fmt.Errorf("backfill start %v is not before end %v", start, end)
+	}
+	if cfg.Window < minBackfillWindow {
+		return backfill{}, fmt.Errorf("backfill window must be at least %v", minBackfillWindow)
+	}
+
+	return backfill{
+		start:    start,
+		end:      end,
+		window:   cfg.Window,
+		maxCount: cfg.MaxCount,
+	}, nil
+}
+
+// checkBackfillQuery returns an error if the query does not declare the
+// $endTime variable, without it a backfill would not be bounded by its window.
+func checkBackfillQuery(r *queryRenderer) error {
+	now := time.Now()
+	query, err := r.Render(QueryData{Watermark: now, Now: now, WindowStart: now, WindowEnd: now})
+	if err != nil {
+		return err
+	}
+	if !declaresVariable(query, "endTime") {
+		return errors.New("backfill requires a query that declares the $endTime variable")
+	}
+	return nil
+}
+
+// nextWindowEnd returns the end of the window starting at start.
+func (b backfill) nextWindowEnd(start time.Time) time.Time {
+	end := start.Add(b.window)
+	if end.After(b.end) {
+		return b.end
+	}
+	return end
+}
+
+// exceeds reports whether a window with the given total count is too large.
+func (b backfill) exceeds(tc TotalCount) bool {
+	if b.maxCount <= 0 {
+		return false
+	}
+	if tc.Value > b.maxCount {
+		return true
+	}
+	// a lower bound that reached the limit may be well above it
+	return tc.Value == b.maxCount && tc.Relation != "" && tc.Relation != TotalCountRelationEqual
+}
+
+// splitWindow halves the current backfill window if the first page of it
+// reports a total count above the limit. It reports whether the window was
+// split, in which case the page has to be requested again.
+func (it *Iterator) splitWindow(ctx context.Context, after string, tc TotalCount) bool {
+	if it.windowEnd.IsZero() || after != "" || !it.backfill.exceeds(tc) {
+		return false
+	}
+
+	size := it.windowEnd.Sub(it.startTime)
+	if size/2 < minBackfillWindow {
+		sdk.Logger(ctx).Warn().
+			Time("start", it.startTime).
+			Time("end", it.windowEnd).
+			Int("totalCount", tc.Value).
+			Msg("Backfill window exceeds the maximum count but can't be split further")
+		return false
+	}
+
+	it.windowEnd = it.startTime.Add(size / 2)
+	sdk.Logger(ctx).Info().
+		Time("start", it.startTime).
+		Time("end", it.windowEnd).
+		Int("totalCount", tc.Value).
+		Str("relation", tc.Relation).
+		Msg("Backfill window exceeds the maximum count, splitting it")
+	return true
+}
@@ -0,0 +1,111 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+)
+
+func TestIterator_Backfill(t *testing.T) {
+	is := is.New(t)
+	day := 24 * time.Hour
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var windows []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables struct {
+				StartTime time.Time `json:"startTime"`
+				EndTime   time.Time `json:"endTime"`
+			} `json:"variables"`
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&req))
+		windowStart, windowEnd := req.Variables.StartTime, req.Variables.EndTime
+		windows = append(windows, windowStart.Format(time.RFC3339)+"/"+windowEnd.Format(time.RFC3339))
+
+		// every day contains 3 vessels, windows longer than a day are too large
+		count := int(windowEnd.Sub(windowStart)/day) * 3
+		_, _ = fmt.Fprintf(w, `{"data":{"vessels":{"totalCount":{"value":%d,"relation":"eq"},"nodes":[{"id":"v","updateTimestamp":%q}]}}}`,
+			count, windowStart.Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	it, err := NewIterator(graphql.NewClient(server.URL), "test-token", "test-query", 100, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{
+		Backfill: BackfillConfig{
+			Enabled:  true,
+			Start:    start.Format(time.RFC3339),
+			End:      start.Add(3 * day).Format(time.RFC3339),
+			Window:   2 * day,
+			MaxCount: 3,
+		},
+	}))
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		is.True(it.HasNext(ctx))
+		record, err := it.Next(ctx)
+		is.NoErr(err)
+
+		pos, err := ParsePosition(record.Position)
+		is.NoErr(err)
+		is.Equal(pos.StartTime, start.Add(time.Duration(i)*day))
+		is.Equal(pos.WindowEnd, start.Add(time.Duration(i+1)*day))
+	}
+
+	is.Equal(windows, []string{
+		"2024-01-01T00:00:00Z/2024-01-03T00:00:00Z", // split
+		"2024-01-01T00:00:00Z/2024-01-02T00:00:00Z",
+		"2024-01-02T00:00:00Z/2024-01-04T00:00:00Z", // split
+		"2024-01-02T00:00:00Z/2024-01-03T00:00:00Z",
+		"2024-01-03T00:00:00Z/2024-01-04T00:00:00Z", // capped at the end
+	})
+
+	// the backfill is complete, polling continues from its end
+	is.True(it.windowEnd.IsZero())
+	is.Equal(it.startTime, start.Add(3*day))
+	is.Equal(it.sweeps, 1)
+}
+
+func TestCheckBackfillQuery(t *testing.T) {
+	testCases := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{"DefaultQuery", vesselQuery(), false},
+		{"WithoutEndTime", `query ($first: Int!, $after: String, $startTime: DateTime!) { vessels { nodes { id } } }`, true},
+		{"EndTimeInSelection", `query ($first: Int!) { vessels(endTime: "$endTime") { nodes { id } } }`, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			r, err := newQueryRenderer(tc.query, nil, "", false)
+			is.NoErr(err)
+			err = checkBackfillQuery(r)
+			is.Equal(err != nil, tc.wantErr)
+		})
+	}
+}
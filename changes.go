@@ -0,0 +1,125 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// MetadataChanged contains a comma separated list of the field paths that
+	// changed since the previous observation of the vessel.
+	MetadataChanged = "ais.changed"
+	// MetadataPatch contains a JSON patch (RFC 6902) that transforms the
+	// previous observation of the vessel into the current one.
+	MetadataPatch = "ais.patch"
+)
+
+type ChangeTrackingConfig struct {
+	// Enabled attaches the paths of the fields that changed since the previous
+	// observation of a vessel to the record metadata.
+	Enabled bool `json:"enabled" default:"false"`
+	// Patch additionally attaches a compact JSON patch describing the changes.
+	Patch bool `json:"patch" default:"false"`
+	// IgnoreFields are field names that are never reported as changed.
+	IgnoreFields []string `json:"ignoreFields" default:"timestamp,updateTimestamp"`
+}
+
+type patchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// changedFields compares two observations of the same vessel and returns the
+// sorted paths of all leaf fields that differ, e.g. "currentVoyage.draught".
+// Fields whose name is in ignore are skipped.
+func changedFields(prev, curr Node, ignore []string) ([]string, error) {
+	prevFields, err := flattenNode(prev)
+	if err != nil {
+		return nil, err
+	}
+	currFields, err := flattenNode(curr)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for path, v := range currFields {
+		if slices.Contains(ignore, path[strings.LastIndex(path, ".")+1:]) {
+			continue
+		}
+		if !reflect.DeepEqual(prevFields[path], v) {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// changePatch returns a JSON patch with a replace operation for each of the
+// changed paths, taking the values from curr.
+func changePatch(curr Node, paths []string) ([]byte, error) {
+	currFields, err := flattenNode(curr)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := make([]patchOperation, len(paths))
+	for i, path := range paths {
+		ops[i] = patchOperation{
+			Op:    "replace",
+			Path:  "/" + strings.ReplaceAll(path, ".", "/"),
+			Value: currFields[path],
+		}
+	}
+	return json.Marshal(ops)
+}
+
+// flattenNode returns the leaf fields of a node keyed by their dot separated
+// JSON path.
+func flattenNode(n Node) (map[string]any, error) {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred marshalling JSON: %w", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("error occurred unmarshalling JSON: %w", err)
+	}
+
+	out := make(map[string]any)
+	flatten("", m, out)
+	return out, nil
+}
+
+func flatten(prefix string, in map[string]any, out map[string]any) {
+	for k, v := range in {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]any); ok {
+			flatten(path, nested, out)
+			continue
+		}
+		out[path] = v
+	}
+}
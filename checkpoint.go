@@ -0,0 +1,154 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+// stateFlushInterval is the minimum time between two writes of the committed
+// vessel state while the source is running.
+const stateFlushInterval = 10 * time.Second
+
+type checkpointEntry struct {
+	position opencdc.Position
+	acked    bool
+	node     Node
+	seen     time.Time
+	deleted  bool
+	// stateless entries only advance the position, e.g. malformed nodes.
+	stateless bool
+}
+
+// checkpointer tracks the records that were emitted but not acknowledged yet.
+// The committed position and vessel state only advance once a record and all
+// records emitted before it are acknowledged.
+type checkpointer struct {
+	mu        sync.Mutex
+	inflight  []checkpointEntry
+	committed Position
+	store     *vesselStore
+	flushed   time.Time
+}
+
+func newCheckpointer(committed Position, store *vesselStore) *checkpointer {
+	return &checkpointer{
+		committed: committed,
+		store:     store,
+		flushed:   time.Now(),
+	}
+}
+
+// Track registers an emitted record. Deleted marks tombstones, which remove
+// the vessel from the committed state once acknowledged.
+func (c *checkpointer) Track(position opencdc.Position, n Node, seen time.Time, deleted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inflight = append(c.inflight, checkpointEntry{
+		position: position,
+		node:     n,
+		seen:     seen,
+		deleted:  deleted,
+	})
+}
+
+// TrackPosition registers an emitted record that does not change the vessel
+// state once acknowledged.
+func (c *checkpointer) TrackPosition(position opencdc.Position) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inflight = append(c.inflight, checkpointEntry{
+		position:  position,
+		stateless: true,
+	})
+}
+
+// Ack marks the record with the given position as acknowledged and reports
+// whether the committed position advanced.
+func (c *checkpointer) Ack(position opencdc.Position) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	found := false
+	for i := range c.inflight {
+		if !c.inflight[i].acked && bytes.Equal(c.inflight[i].position, position) {
+			c.inflight[i].acked = true
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false, fmt.Errorf("received ack for unknown position %q", string(position))
+	}
+
+	advanced := false
+	for len(c.inflight) > 0 && c.inflight[0].acked {
+		e := c.inflight[0]
+		c.inflight = c.inflight[1:]
+
+		pos, err := ParsePosition(e.position)
+		if err != nil {
+			return advanced, err
+		}
+		c.committed = pos
+		switch {
+		case e.stateless:
+		case e.deleted:
+			c.store.Delete(e.node.ID)
+		default:
+			c.store.Put(e.node, e.seen)
+		}
+		advanced = true
+	}
+	return advanced, nil
+}
+
+// Committed returns the position of the last record that was acknowledged
+// together with all records before it.
+func (c *checkpointer) Committed() Position {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.committed
+}
+
+// Inflight returns the number of records that were not committed yet.
+func (c *checkpointer) Inflight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.inflight)
+}
+
+// Flush writes the committed vessel state to path. If interval is positive the
+// state is only written if the last flush is older than interval.
+func (c *checkpointer) Flush(path string, interval time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if path == "" || (interval > 0 && time.Since(c.flushed) < interval) {
+		return nil
+	}
+	if err := c.store.Save(path); err != nil {
+		return err
+	}
+	c.flushed = time.Now()
+	return nil
+}
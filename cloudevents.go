@@ -0,0 +1,114 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	PayloadEnvelopeNone        = "none"
+	PayloadEnvelopeCloudEvents = "cloudevents"
+)
+
+// cloudEventsSpecVersion is the version of the CloudEvents specification
+// events conform to.
+const cloudEventsSpecVersion = "1.0"
+
+// CloudEventsConfig configures the CloudEvents envelope.
+type CloudEventsConfig struct {
+	// Type is the type of events for vessels read from the API.
+	Type string `json:"type" default:"com.spire.ais.vessel.position"`
+	// RemovedType is the type of events for vessels that became stale.
+	RemovedType string `json:"removedType" default:"com.spire.ais.vessel.removed"`
+}
+
+// cloudEvent is a CloudEvents 1.0 event in the structured JSON format.
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+}
+
+// cloudEventsEnvelope wraps payloads as CloudEvents.
+type cloudEventsEnvelope struct {
+	source      string
+	eventType   string
+	removedType string
+	contentType string
+	binary      bool
+}
+
+func newCloudEventsEnvelope(cfg SourceConfig) *cloudEventsEnvelope {
+	e := &cloudEventsEnvelope{
+		source:      cfg.APIURL + "#" + queryName(cfg),
+		eventType:   cfg.Payload.CloudEvents.Type,
+		removedType: cfg.Payload.CloudEvents.RemovedType,
+		contentType: "application/json",
+	}
+	switch {
+	case cfg.Payload.Format == PayloadFormatGeoJSON:
+		e.contentType = "application/geo+json"
+	case cfg.Payload.Encoding == PayloadEncodingProtobuf:
+		e.contentType, e.binary = "application/protobuf", true
+	case cfg.Payload.Encoding == PayloadEncodingAvro:
+		e.contentType, e.binary = "application/avro", true
+	}
+	return e
+}
+
+// Wrap returns the payload of the vessel as a CloudEvent. The event ID is
+// derived from the vessel and its update timestamp, so an update read twice
+// has the same ID. Removal events get a suffix to keep their ID distinct from
+// the last position event of the vessel.
+func (e *cloudEventsEnvelope) Wrap(n Node, payload []byte, removed bool) ([]byte, error) {
+	ev := cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              n.ID + "@" + n.UpdateTimestamp,
+		Source:          e.source,
+		Type:            e.eventType,
+		DataContentType: e.contentType,
+	}
+	if removed {
+		ev.ID += "#removed"
+		ev.Type = e.removedType
+	}
+	if n.StaticData.MMSI != 0 {
+		ev.Subject = strconv.Itoa(n.StaticData.MMSI)
+	}
+	if t, err := time.Parse(time.RFC3339, n.UpdateTimestamp); err == nil {
+		ev.Time = t.UTC().Format(time.RFC3339Nano)
+	}
+	if e.binary {
+		ev.DataBase64 = payload
+	} else {
+		ev.Data = payload
+	}
+
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred marshalling CloudEvent: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,137 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+)
+
+func TestCloudEventsEnvelope(t *testing.T) {
+	cfg := func(payload PayloadConfig) SourceConfig {
+		payload.CloudEvents = CloudEventsConfig{
+			Type:        "com.spire.ais.vessel.position",
+			RemovedType: "com.spire.ais.vessel.removed",
+		}
+		return SourceConfig{
+			Config:  Config{APIURL: "https://api.spire.com/graphql"},
+			Payload: payload,
+		}
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		is := is.New(t)
+		e := newCloudEventsEnvelope(cfg(PayloadConfig{}))
+		b, err := e.Wrap(testVessel, []byte(`{"id":"vessel-1"}`), false)
+		is.NoErr(err)
+
+		var ev map[string]any
+		is.NoErr(json.Unmarshal(b, &ev))
+		is.Equal(ev, map[string]any{
+			"specversion":     "1.0",
+			"id":              "vessel-1@2024-01-01T12:00:00Z",
+			"source":          "https://api.spire.com/graphql#vessels",
+			"type":            "com.spire.ais.vessel.position",
+			"subject":         "244660000",
+			"time":            "2024-01-01T12:00:00Z",
+			"datacontenttype": "application/json",
+			"data":            map[string]any{"id": "vessel-1"},
+		})
+	})
+
+	t.Run("Binary", func(t *testing.T) {
+		is := is.New(t)
+		e := newCloudEventsEnvelope(cfg(PayloadConfig{Encoding: PayloadEncodingProtobuf}))
+		b, err := e.Wrap(Node{ID: "vessel-2"}, []byte{0x0a, 0x01}, true)
+		is.NoErr(err)
+
+		var ev map[string]any
+		is.NoErr(json.Unmarshal(b, &ev))
+		is.Equal(ev["type"], "com.spire.ais.vessel.removed")
+		is.Equal(ev["datacontenttype"], "application/protobuf")
+		is.Equal(ev["data_base64"], base64.StdEncoding.EncodeToString([]byte{0x0a, 0x01}))
+		_, ok := ev["data"]
+		is.True(!ok)
+		// subject and time are omitted if the vessel has no MMSI and timestamp
+		_, ok = ev["subject"]
+		is.True(!ok)
+		_, ok = ev["time"]
+		is.True(!ok)
+	})
+
+	t.Run("RemovedID", func(t *testing.T) {
+		is := is.New(t)
+		e := newCloudEventsEnvelope(cfg(PayloadConfig{}))
+		b, err := e.Wrap(testVessel, []byte(`{}`), false)
+		is.NoErr(err)
+		var position map[string]any
+		is.NoErr(json.Unmarshal(b, &position))
+
+		b, err = e.Wrap(testVessel, []byte(`{}`), true)
+		is.NoErr(err)
+		var removed map[string]any
+		is.NoErr(json.Unmarshal(b, &removed))
+
+		is.Equal(removed["id"], "vessel-1@2024-01-01T12:00:00Z#removed")
+		is.True(removed["id"] != position["id"])
+	})
+}
+
+func TestIterator_CloudEvents(t *testing.T) {
+	is := is.New(t)
+
+	client := &MockGraphQLClient{
+		RunFn: func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+			arg := resp.(*struct{ Vessels Vessels })
+			arg.Vessels.Nodes = []Node{testVessel}
+			return nil
+		},
+	}
+	query := strings.Replace(vesselQuery(), "query (", "query Tankers (", 1)
+	it, err := NewIterator(client, "test-token", query, 1, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{
+		Config:        Config{APIURL: "https://api.spire.com/graphql"},
+		OperationName: "Tankers",
+		Payload: PayloadConfig{
+			Format:   PayloadFormatGeoJSON,
+			Envelope: PayloadEnvelopeCloudEvents,
+			CloudEvents: CloudEventsConfig{
+				Type: "com.spire.ais.vessel.position",
+			},
+		},
+	}))
+
+	record, err := it.Next(context.Background())
+	is.NoErr(err)
+
+	var ev struct {
+		Source          string         `json:"source"`
+		Subject         string         `json:"subject"`
+		DataContentType string         `json:"datacontenttype"`
+		Data            map[string]any `json:"data"`
+	}
+	is.NoErr(json.Unmarshal(record.Payload.After.Bytes(), &ev))
+	is.Equal(ev.Source, "https://api.spire.com/graphql#Tankers")
+	is.Equal(ev.Subject, "244660000")
+	is.Equal(ev.DataContentType, "application/geo+json")
+	is.Equal(ev.Data["type"], "Feature")
+}
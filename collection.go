@@ -0,0 +1,171 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+// errNotInMapping is returned by the group template function for values
+// without a group.
+var errNotInMapping = errors.New("value is not in the mapping")
+
+// errEmptyValue is returned by the lower and upper template functions for
+// empty values, e.g. an unknown ship type.
+var errEmptyValue = errors.New("value is empty")
+
+type CollectionConfig struct {
+	// Template is a Go text/template rendered with the vessel node that sets
+	// the collection of each record, e.g. "ais_{{ .StaticData.ShipType |
+	// lower }}".
+	Template string `json:"template"`
+	// Mapping groups values in the template using the "group" function, e.g.
+	// "ais_{{ .StaticData.ShipType | group }}".
+	Mapping map[string]string `json:"mapping"`
+	// Default is the collection of vessels for which the template renders an
+	// empty string, prints an empty field or a value that is not in the
+	// mapping.
+	Default string `json:"default"`
+}
+
+// collectionRouter determines the collection of vessel records.
+type collectionRouter struct {
+	tmpl     *template.Template
+	fallback string
+	// printed are the node fields the template prints, e.g.
+	// ["StaticData", "ShipType"].
+	printed [][]string
+}
+
+func newCollectionRouter(cfg CollectionConfig) (*collectionRouter, error) {
+	r := &collectionRouter{fallback: cfg.Default}
+	if cfg.Template == "" {
+		return r, nil
+	}
+
+	tmpl, err := template.New("collection").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"lower": func(v string) (string, error) {
+				if v == "" {
+					return "", errEmptyValue
+				}
+				return strings.ToLower(v), nil
+			},
+			"upper": func(v string) (string, error) {
+				if v == "" {
+					return "", errEmptyValue
+				}
+				return strings.ToUpper(v), nil
+			},
+			"group": func(v string) (string, error) {
+				group, ok := cfg.Mapping[v]
+				if !ok {
+					return "", fmt.Errorf("%q: %w", v, errNotInMapping)
+				}
+				return group, nil
+			},
+		}).
+		Parse(cfg.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collection template: %w", err)
+	}
+	// render an empty node to catch references to unknown fields, unknown
+	// groups and empty values are expected
+	err = tmpl.Execute(&strings.Builder{}, Node{})
+	if err != nil && !errors.Is(err, errNotInMapping) && !errors.Is(err, errEmptyValue) {
+		return nil, fmt.Errorf("invalid collection template: %w", err)
+	}
+	r.tmpl = tmpl
+	r.printed = printedFields(tmpl.Root)
+	return r, nil
+}
+
+// printedFields returns the fields of the node printed by the actions of the
+// template. Fields only used in conditions are not included, neither are the
+// fields in range and with blocks, which change the dot.
+func printedFields(list *parse.ListNode) [][]string {
+	if list == nil {
+		return nil
+	}
+	var fields [][]string
+	for _, node := range list.Nodes {
+		switch node := node.(type) {
+		case *parse.ActionNode:
+			if len(node.Pipe.Decl) > 0 {
+				continue // variable assignment
+			}
+			for _, cmd := range node.Pipe.Cmds {
+				for _, arg := range cmd.Args {
+					if f, ok := arg.(*parse.FieldNode); ok {
+						fields = append(fields, f.Ident)
+					}
+				}
+			}
+		case *parse.IfNode:
+			fields = append(fields, printedFields(node.List)...)
+			fields = append(fields, printedFields(node.ElseList)...)
+		}
+	}
+	return fields
+}
+
+// emptyField reports whether any of the fields is the zero value in the node.
+func emptyField(n Node, fields [][]string) bool {
+	for _, path := range fields {
+		v := reflect.ValueOf(n)
+		for _, name := range path {
+			if v.Kind() != reflect.Struct {
+				break
+			}
+			v = v.FieldByName(name)
+		}
+		if v.IsValid() && v.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// setCollection sets the collection of the record of a vessel if collection
+// routing is configured.
+func (it *Iterator) setCollection(n Node, metadata opencdc.Metadata) {
+	if it.collections == nil {
+		return
+	}
+	if c := it.collections.Collection(n); c != "" {
+		metadata.SetCollection(c)
+	}
+}
+
+// Collection returns the collection of the vessel, or an empty string if the
+// record should not have a collection.
+func (r *collectionRouter) Collection(n Node) string {
+	if r.tmpl == nil {
+		return r.fallback
+	}
+	var sb strings.Builder
+	if err := r.tmpl.Execute(&sb, n); err != nil || sb.Len() == 0 || emptyField(n, r.printed) {
+		return r.fallback
+	}
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestCollectionRouter(t *testing.T) {
+	vessel := func(shipType string) Node {
+		return Node{StaticData: StaticData{ShipType: shipType}}
+	}
+
+	t.Run("Template", func(t *testing.T) {
+		is := is.New(t)
+		r, err := newCollectionRouter(CollectionConfig{
+			Template: "ais_{{ .StaticData.ShipType | lower }}",
+		})
+		is.NoErr(err)
+		is.Equal(r.Collection(vessel("TANKER")), "ais_tanker")
+	})
+
+	t.Run("EmptyField", func(t *testing.T) {
+		is := is.New(t)
+		for _, template := range []string{
+			"ais_{{ .StaticData.ShipType | lower }}",
+			"ais_{{ .StaticData.ShipType }}",
+		} {
+			r, err := newCollectionRouter(CollectionConfig{
+				Template: template,
+				Default:  "ais_unknown",
+			})
+			is.NoErr(err)
+			is.Equal(r.Collection(vessel("")), "ais_unknown")
+			is.True(r.Collection(vessel("TANKER")) != "ais_unknown")
+		}
+	})
+
+	t.Run("Constant", func(t *testing.T) {
+		is := is.New(t)
+		r, err := newCollectionRouter(CollectionConfig{Template: "ais_vessels"})
+		is.NoErr(err)
+		is.Equal(r.Collection(vessel("")), "ais_vessels")
+		is.Equal(r.Collection(vessel("TANKER")), "ais_vessels")
+	})
+
+	t.Run("Conditional", func(t *testing.T) {
+		is := is.New(t)
+		r, err := newCollectionRouter(CollectionConfig{
+			Template: `{{if eq .StaticData.ShipType "TANKER"}}tankers{{else}}other{{end}}`,
+			Default:  "ais_unknown",
+		})
+		is.NoErr(err)
+		is.Equal(r.Collection(vessel("TANKER")), "tankers")
+		is.Equal(r.Collection(vessel("CARGO")), "other")
+		// the ship type is only compared, not printed
+		is.Equal(r.Collection(vessel("")), "other")
+	})
+
+	t.Run("Mapping", func(t *testing.T) {
+		is := is.New(t)
+		r, err := newCollectionRouter(CollectionConfig{
+			Template: "ais_{{ .StaticData.ShipType | group }}",
+			Mapping: map[string]string{
+				"TANKER":             "tankers",
+				"TANKER_PRODUCT":     "tankers",
+				"GENERAL_CARGO":      "cargo",
+				"FISHING":            "fishing",
+				"PASSENGER_FERRY_RO": "passenger",
+			},
+			Default: "ais_other",
+		})
+		is.NoErr(err)
+		is.Equal(r.Collection(vessel("TANKER_PRODUCT")), "ais_tankers")
+		is.Equal(r.Collection(vessel("GENERAL_CARGO")), "ais_cargo")
+		is.Equal(r.Collection(vessel("PLEASURE_CRAFT")), "ais_other")
+		is.Equal(r.Collection(vessel("")), "ais_other")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		is := is.New(t)
+		_, err := newCollectionRouter(CollectionConfig{Template: "{{ .StaticData.Type }}"})
+		is.True(err != nil)
+		_, err = newCollectionRouter(CollectionConfig{Template: "{{ .StaticData.ShipType | title }}"})
+		is.True(err != nil)
+	})
+}
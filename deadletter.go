@@ -0,0 +1,72 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+)
+
+const (
+	ErrorModeStrict  = "strict"
+	ErrorModeLenient = "lenient"
+)
+
+// MetadataError is the metadata key carrying the reason a node is malformed.
+const MetadataError = "ais.error"
+
+type ErrorsConfig struct {
+	// Mode determines how nodes that cannot be converted to records, e.g.
+	// because of an unparseable update timestamp, are handled: "strict"
+	// stops the pipeline with an error, "lenient" emits them to Collection
+	// or skips them.
+	Mode string `json:"mode" default:"strict" validate:"inclusion=strict|lenient"`
+	// Collection is the collection malformed nodes are emitted to in lenient
+	// mode. If empty, malformed nodes are skipped.
+	Collection string `json:"collection"`
+}
+
+// malformed handles a node that could not be converted to a record. In strict
+// mode the cause is returned. In lenient mode the node is emitted as is to the
+// error collection, or skipped if there is none, in which case false is
+// returned.
+func (it *Iterator) malformed(ctx context.Context, n Node, page fetchedPage, cause error) (opencdc.Record, bool, error) {
+	if it.errors.Mode != ErrorModeLenient {
+		return opencdc.Record{}, false, cause
+	}
+	it.nodesMalformed++
+
+	if it.errors.Collection == "" {
+		sdk.Logger(ctx).Warn().Err(cause).Str("id", n.ID).Msg("skipping malformed vessel node")
+		return opencdc.Record{}, false, nil
+	}
+
+	b, err := json.Marshal(n)
+	if err != nil {
+		return opencdc.Record{}, false, fmt.Errorf("error occurred marshalling JSON: %w", err)
+	}
+	metadata := opencdc.Metadata{MetadataError: cause.Error()}
+	metadata.SetCollection(it.errors.Collection)
+	setVesselMetadata(n, metadata)
+	setPageMetadata(page, metadata)
+
+	record := sdk.Util.Source.NewRecordCreate(it.position, metadata, opencdc.RawData(n.ID), opencdc.RawData(b))
+	it.checkpoints.TrackPosition(record.Position)
+	return record, true, nil
+}
@@ -0,0 +1,87 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+)
+
+func TestIterator_Malformed(t *testing.T) {
+	ctx := context.Background()
+	newIterator := func(is *is.I, cfg ErrorsConfig) *Iterator {
+		client := &MockGraphQLClient{
+			RunFn: func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+				arg := resp.(*struct{ Vessels Vessels })
+				arg.Vessels.Nodes = []Node{
+					{ID: "bad", UpdateTimestamp: "yesterday", StaticData: StaticData{MMSI: 244660000}},
+					{ID: "good", UpdateTimestamp: "2024-01-01T00:00:00Z"},
+				}
+				return nil
+			},
+		}
+		it, err := NewIterator(client, "test-token", vesselQuery(), 2, nil)
+		is.NoErr(err)
+		is.NoErr(it.applyConfig(SourceConfig{Errors: cfg}))
+		return it
+	}
+
+	t.Run("Strict", func(t *testing.T) {
+		is := is.New(t)
+		it := newIterator(is, ErrorsConfig{Mode: ErrorModeStrict})
+		_, err := it.Next(ctx)
+		is.True(err != nil)
+	})
+
+	t.Run("Collection", func(t *testing.T) {
+		is := is.New(t)
+		it := newIterator(is, ErrorsConfig{Mode: ErrorModeLenient, Collection: "ais_errors"})
+
+		dead, err := it.Next(ctx)
+		is.NoErr(err)
+		is.Equal(dead.Key, opencdc.RawData("bad"))
+		collection, err := dead.Metadata.GetCollection()
+		is.NoErr(err)
+		is.Equal(collection, "ais_errors")
+		is.True(dead.Metadata[MetadataError] != "")
+		is.Equal(dead.Metadata[MetadataMMSI], "244660000")
+
+		good, err := it.Next(ctx)
+		is.NoErr(err)
+		is.Equal(good.Key, opencdc.RawData("good"))
+		is.Equal(it.nodesMalformed, 1)
+
+		// the malformed node advances the position but not the vessel state
+		is.NoErr(it.Ack(ctx, dead.Position))
+		_, ok := it.checkpoints.store.Get("bad")
+		is.True(!ok)
+		is.NoErr(it.Ack(ctx, good.Position))
+		is.Equal(it.checkpoints.Inflight(), 0)
+	})
+
+	t.Run("Skip", func(t *testing.T) {
+		is := is.New(t)
+		it := newIterator(is, ErrorsConfig{Mode: ErrorModeLenient})
+
+		record, err := it.Next(ctx)
+		is.NoErr(err)
+		is.Equal(record.Key, opencdc.RawData("good"))
+		is.Equal(it.nodesMalformed, 1)
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import "fmt"
+
+// derivedKey is the key of the derived fields in JSON payloads.
+const derivedKey = "derived"
+
+type DeriveConfig struct {
+	// RateOfTurn decodes the AIS rate of turn indicator into degrees per
+	// minute and a turn state.
+	RateOfTurn bool `json:"rateOfTurn" default:"false"`
+	// Geometry derives the length, beam and antenna offset of the vessel
+	// from the AIS dimensions.
+	Geometry bool `json:"geometry" default:"false"`
+	// HullPolygon adds the outline of the hull around the reported position
+	// to the geometry, oriented by the heading or, if not available, the
+	// course.
+	HullPolygon bool `json:"hullPolygon" default:"false"`
+	// MMSI decodes the type and country of the MMSI, validates it and
+	// compares the country with the flag of the vessel.
+	MMSI bool `json:"mmsi" default:"false"`
+}
+
+// enabled reports whether any derived field is configured.
+func (c DeriveConfig) enabled() bool {
+	return c.RateOfTurn || c.Geometry || c.MMSI
+}
+
+// validate returns an error if an option is set without the option it
+// depends on.
+func (c DeriveConfig) validate() error {
+	if c.HullPolygon && !c.Geometry {
+		return fmt.Errorf("%q requires %q", SourceConfigDeriveHullPolygon, SourceConfigDeriveGeometry)
+	}
+	return nil
+}
+
+// derivedFields are computed from the fields of a node and added to JSON
+// payloads.
+type derivedFields struct {
+	RateOfTurn *rateOfTurn     `json:"rateOfTurn,omitempty"`
+	Geometry   *vesselGeometry `json:"geometry,omitempty"`
+	MMSI       *mmsiInfo       `json:"mmsi,omitempty"`
+}
+
+// derive returns the configured derived fields of the node as returned by the
+// API, before sentinel values are normalized.
+func (c DeriveConfig) derive(n Node) (derivedFields, error) {
+	var d derivedFields
+	if c.RateOfTurn {
+		rot := decodeRateOfTurn(n.LastPositionUpdate.Rot)
+		d.RateOfTurn = &rot
+	}
+	if c.Geometry {
+		d.Geometry = deriveGeometry(n, c.HullPolygon)
+	}
+	if c.MMSI && n.StaticData.MMSI != 0 {
+		info, err := decodeMMSI(n.StaticData.MMSI, n.StaticData.Flag)
+		if err != nil {
+			return derivedFields{}, err
+		}
+		d.MMSI = &info
+	}
+	return d, nil
+}
@@ -0,0 +1,187 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	PayloadEncodingJSON     = "json"
+	PayloadEncodingProtobuf = "protobuf"
+	PayloadEncodingAvro     = "avro"
+)
+
+// Metadata keys identifying the schema of binary payloads.
+const (
+	MetadataSchemaSubject = "spire.schema.subject"
+	MetadataSchemaVersion = "spire.schema.version"
+)
+
+// schemaVersion is the version of the published vessel schemas. It is
+// incremented with every change to schema/vessel.proto or schema/vessel.avsc.
+const schemaVersion = 1
+
+//go:embed schema/vessel.proto schema/vessel.avsc
+var schemas embed.FS
+
+// schemaEncoder encodes vessel nodes in a binary format described by one of
+// the published schemas.
+type schemaEncoder interface {
+	Encode(n Node) ([]byte, error)
+	Decode(b []byte) (Node, error)
+	// Subject is the full name of the vessel type in the schema.
+	Subject() string
+}
+
+func newSchemaEncoder(encoding string) (schemaEncoder, error) {
+	switch encoding {
+	case PayloadEncodingProtobuf:
+		return newProtobufEncoder()
+	case PayloadEncodingAvro:
+		return newAvroEncoder()
+	default:
+		return nil, fmt.Errorf("unknown payload encoding %q", encoding)
+	}
+}
+
+// setSchemaMetadata adds the subject and version of the payload schema to the
+// metadata.
+func setSchemaMetadata(enc schemaEncoder, metadata opencdc.Metadata) {
+	metadata[MetadataSchemaSubject] = enc.Subject()
+	metadata[MetadataSchemaVersion] = strconv.Itoa(schemaVersion)
+}
+
+// protobufEncoder encodes nodes as the Vessel message of schema/vessel.proto.
+// The JSON names of the message fields match the JSON names of the model, so
+// nodes are converted using their JSON representation.
+type protobufEncoder struct {
+	desc protoreflect.MessageDescriptor
+}
+
+func newProtobufEncoder() (*protobufEncoder, error) {
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: func(path string) (io.ReadCloser, error) {
+				return schemas.Open(path)
+			},
+		},
+	}
+	files, err := compiler.Compile(context.Background(), "schema/vessel.proto")
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile protobuf schema: %w", err)
+	}
+	desc := files[0].Messages().ByName("Vessel")
+	if desc == nil {
+		return nil, errors.New("protobuf schema has no Vessel message")
+	}
+	return &protobufEncoder{desc: desc}, nil
+}
+
+func (e *protobufEncoder) Encode(n Node) ([]byte, error) {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred marshalling JSON: %w", err)
+	}
+	msg := dynamicpb.NewMessage(e.desc)
+	if err := protojson.Unmarshal(b, msg); err != nil {
+		return nil, fmt.Errorf("failed to convert vessel to protobuf: %w", err)
+	}
+	b, err = proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred marshalling protobuf: %w", err)
+	}
+	return b, nil
+}
+
+func (e *protobufEncoder) Decode(b []byte) (Node, error) {
+	msg := dynamicpb.NewMessage(e.desc)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return Node{}, fmt.Errorf("error occurred unmarshalling protobuf: %w", err)
+	}
+	b, err := protojson.Marshal(msg)
+	if err != nil {
+		return Node{}, fmt.Errorf("failed to convert protobuf to vessel: %w", err)
+	}
+	var n Node
+	if err := json.Unmarshal(b, &n); err != nil {
+		return Node{}, fmt.Errorf("error occurred unmarshalling JSON: %w", err)
+	}
+	return n, nil
+}
+
+func (e *protobufEncoder) Subject() string {
+	return string(e.desc.FullName())
+}
+
+// avroEncoder encodes nodes as the Vessel record of schema/vessel.avsc. The
+// record fields are matched with the JSON names of the model.
+type avroEncoder struct {
+	schema *avro.RecordSchema
+	api    avro.API
+}
+
+func newAvroEncoder() (*avroEncoder, error) {
+	b, err := schemas.ReadFile("schema/vessel.avsc")
+	if err != nil {
+		return nil, err
+	}
+	schema, err := avro.ParseBytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse avro schema: %w", err)
+	}
+	record, ok := schema.(*avro.RecordSchema)
+	if !ok {
+		return nil, fmt.Errorf("avro schema is a %s, expected a record", schema.Type())
+	}
+	return &avroEncoder{
+		schema: record,
+		api:    avro.Config{TagKey: "json"}.Freeze(),
+	}, nil
+}
+
+func (e *avroEncoder) Encode(n Node) ([]byte, error) {
+	b, err := e.api.Marshal(e.schema, n)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred marshalling avro: %w", err)
+	}
+	return b, nil
+}
+
+func (e *avroEncoder) Decode(b []byte) (Node, error) {
+	var n Node
+	if err := e.api.Unmarshal(e.schema, b, &n); err != nil {
+		return Node{}, fmt.Errorf("error occurred unmarshalling avro: %w", err)
+	}
+	return n, nil
+}
+
+func (e *avroEncoder) Subject() string {
+	return e.schema.FullName()
+}
@@ -0,0 +1,171 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hamba/avro/v2"
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var testVessel = Node{
+	ID:              "vessel-1",
+	UpdateTimestamp: "2024-01-01T12:00:00Z",
+	StaticData: StaticData{
+		AisClass:        "A",
+		Flag:            "NL",
+		Name:            "ALMA",
+		Callsign:        "PBAB",
+		Timestamp:       "2024-01-01T11:00:00Z",
+		UpdateTimestamp: "2024-01-01T11:00:00Z",
+		ShipType:        "TANKER",
+		ShipSubType:     "TANKER_PRODUCT",
+		MMSI:            244660000,
+		IMO:             9321483,
+		Dimensions:      Dimensions{A: 150, B: 33, C: 16, D: 16, Width: 32, Length: 183},
+	},
+	LastPositionUpdate: LastPositionUpdate{
+		Accuracy:           "HIGH",
+		CollectionType:     "SATELLITE",
+		Course:             271.3,
+		Heading:            270,
+		Latitude:           52.37,
+		Longitude:          4.89,
+		Maneuver:           "NOT_AVAILABLE",
+		NavigationalStatus: "UNDER_WAY_USING_ENGINE",
+		Rot:                -1.5,
+		Speed:              12.5,
+		Timestamp:          "2024-01-01T12:00:00Z",
+		UpdateTimestamp:    "2024-01-01T12:00:00Z",
+	},
+	CurrentVoyage: CurrentVoyage{
+		Destination:     "ROTTERDAM",
+		Draught:         10.2,
+		ETA:             "2024-01-03T06:00:00Z",
+		Timestamp:       "2024-01-01T10:00:00Z",
+		UpdateTimestamp: "2024-01-01T10:00:00Z",
+	},
+}
+
+func TestSchemaEncoder(t *testing.T) {
+	for _, encoding := range []string{PayloadEncodingProtobuf, PayloadEncodingAvro} {
+		t.Run(encoding, func(t *testing.T) {
+			is := is.New(t)
+			enc, err := newSchemaEncoder(encoding)
+			is.NoErr(err)
+			is.Equal(enc.Subject(), "spire.ais.v1.Vessel")
+
+			for _, n := range []Node{testVessel, {ID: "empty"}} {
+				b, err := enc.Encode(n)
+				is.NoErr(err)
+				got, err := enc.Decode(b)
+				is.NoErr(err)
+				is.Equal(got, n)
+
+				// the JSON model is unchanged by the round trip
+				want, err := json.Marshal(n)
+				is.NoErr(err)
+				gotJSON, err := json.Marshal(got)
+				is.NoErr(err)
+				is.Equal(string(gotJSON), string(want))
+			}
+		})
+	}
+}
+
+func TestSchemaEncoder_Fields(t *testing.T) {
+	model := modelFields("", reflect.TypeOf(Node{}))
+	slices.Sort(model)
+
+	t.Run("protobuf", func(t *testing.T) {
+		is := is.New(t)
+		enc, err := newProtobufEncoder()
+		is.NoErr(err)
+
+		var fields func(prefix string, desc protoreflect.MessageDescriptor) []string
+		fields = func(prefix string, desc protoreflect.MessageDescriptor) []string {
+			var out []string
+			for i := 0; i < desc.Fields().Len(); i++ {
+				f := desc.Fields().Get(i)
+				if f.Message() != nil {
+					out = append(out, fields(prefix+f.JSONName()+".", f.Message())...)
+					continue
+				}
+				out = append(out, prefix+f.JSONName())
+			}
+			return out
+		}
+		got := fields("", enc.desc)
+		slices.Sort(got)
+		is.Equal(got, model)
+	})
+
+	t.Run("avro", func(t *testing.T) {
+		is := is.New(t)
+		enc, err := newAvroEncoder()
+		is.NoErr(err)
+
+		var fields func(prefix string, schema *avro.RecordSchema) []string
+		fields = func(prefix string, schema *avro.RecordSchema) []string {
+			var out []string
+			for _, f := range schema.Fields() {
+				if rec, ok := f.Type().(*avro.RecordSchema); ok {
+					out = append(out, fields(prefix+f.Name()+".", rec)...)
+					continue
+				}
+				out = append(out, prefix+f.Name())
+			}
+			return out
+		}
+		got := fields("", enc.schema)
+		slices.Sort(got)
+		is.Equal(got, model)
+	})
+}
+
+func TestIterator_PayloadEncoding(t *testing.T) {
+	is := is.New(t)
+
+	client := &MockGraphQLClient{
+		RunFn: func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+			arg := resp.(*struct{ Vessels Vessels })
+			arg.Vessels.Nodes = []Node{testVessel}
+			return nil
+		},
+	}
+	it, err := NewIterator(client, "test-token", vesselQuery(), 1, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{Payload: PayloadConfig{Encoding: PayloadEncodingAvro}}))
+
+	record, err := it.Next(context.Background())
+	is.NoErr(err)
+	is.Equal(record.Metadata[MetadataSchemaSubject], "spire.ais.v1.Vessel")
+	is.Equal(record.Metadata[MetadataSchemaVersion], "1")
+
+	enc, err := newAvroEncoder()
+	is.NoErr(err)
+	got, err := enc.Decode(record.Payload.After.Bytes())
+	is.NoErr(err)
+	is.Equal(got, testVessel)
+	is.True(!strings.HasPrefix(string(record.Payload.After.Bytes()), "{"))
+}
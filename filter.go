@@ -0,0 +1,169 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+)
+
+// nodeFilter drops nodes for which a CEL expression evaluates to true.
+//
+// The expression can access the fields of a node by their JSON names, e.g.
+// "lastPositionUpdate.speed". Fields of the nested objects are also available
+// directly if their name is unique, e.g. "speed" or "shipType". Nullable
+// fields, e.g. normalized sentinel values, are null if they are not available.
+type nodeFilter struct {
+	program  cel.Program
+	promoted map[string]promotedField
+}
+
+func newNodeFilter(expression string, nullable []string) (*nodeFilter, error) {
+	var opts []cel.EnvOption
+	for name, t := range filterVariables(nullable) {
+		opts = append(opts, cel.Variable(name, t))
+	}
+	opts = append(opts, cel.CrossTypeNumericComparisons(true))
+
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating filter environment: %w", err)
+	}
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expression, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("invalid filter %q: expression returns %v instead of bool", expression, ast.OutputType())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expression, err)
+	}
+	return &nodeFilter{program: program, promoted: promotedFields()}, nil
+}
+
+// Match reports whether the expression is true for the node. The fields at
+// the null paths are null.
+func (f *nodeFilter) Match(n Node, null []string) (bool, error) {
+	vars := structValues(reflect.ValueOf(n))
+	for _, path := range null {
+		object, name, _ := strings.Cut(path, ".")
+		if m, ok := vars[object].(map[string]any); ok {
+			m[name] = nil
+		}
+	}
+	for name, p := range f.promoted {
+		vars[name] = vars[p.object].(map[string]any)[name]
+	}
+
+	out, _, err := f.program.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	match, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("filter returned %v instead of bool", out.Value())
+	}
+	return match, nil
+}
+
+// filterVariables returns the variables available in filter expressions and
+// their types.
+func filterVariables(nullable []string) map[string]*cel.Type {
+	vars := make(map[string]*cel.Type)
+	t := reflect.TypeOf(Node{})
+	for i := 0; i < t.NumField(); i++ {
+		vars[jsonName(t.Field(i))] = celType(t.Field(i).Type)
+	}
+	for name, p := range promotedFields() {
+		vars[name] = celType(p.field.Type)
+		if slices.Contains(nullable, p.object+"."+name) {
+			vars[name] = cel.NullableType(vars[name])
+		}
+	}
+	return vars
+}
+
+type promotedField struct {
+	object string
+	field  reflect.StructField
+}
+
+// promotedFields returns the fields of the nested objects of a node that have
+// a unique name and can be accessed without the object name.
+func promotedFields() map[string]promotedField {
+	t := reflect.TypeOf(Node{})
+	seen := make(map[string]int)
+	fields := make(map[string]promotedField)
+	for i := 0; i < t.NumField(); i++ {
+		seen[jsonName(t.Field(i))]++
+	}
+	for i := 0; i < t.NumField(); i++ {
+		obj := t.Field(i)
+		if obj.Type.Kind() != reflect.Struct {
+			continue
+		}
+		for j := 0; j < obj.Type.NumField(); j++ {
+			f := obj.Type.Field(j)
+			seen[jsonName(f)]++
+			fields[jsonName(f)] = promotedField{object: jsonName(obj), field: f}
+		}
+	}
+	for name := range fields {
+		if seen[name] > 1 {
+			delete(fields, name)
+		}
+	}
+	return fields
+}
+
+func celType(t reflect.Type) *cel.Type {
+	switch t.Kind() {
+	case reflect.String:
+		return cel.StringType
+	case reflect.Int, reflect.Int64:
+		return cel.IntType
+	case reflect.Float64:
+		return cel.DoubleType
+	case reflect.Bool:
+		return cel.BoolType
+	default:
+		return cel.MapType(cel.StringType, cel.DynType)
+	}
+}
+
+// structValues converts a struct to a map keyed by the JSON field names.
+func structValues(v reflect.Value) map[string]any {
+	out := make(map[string]any, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() == reflect.Struct {
+			out[jsonName(v.Type().Field(i))] = structValues(f)
+			continue
+		}
+		out[jsonName(v.Type().Field(i))] = f.Interface()
+	}
+	return out
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
@@ -0,0 +1,144 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+)
+
+func TestNodeFilter(t *testing.T) {
+	moored := Node{
+		ID:                 "a",
+		StaticData:         StaticData{MMSI: 244660000, ShipType: "TANKER"},
+		LastPositionUpdate: LastPositionUpdate{Speed: 14.2, NavigationalStatus: "MOORED"},
+	}
+
+	testCases := []struct {
+		expression string
+		want       bool
+	}{
+		{`speed > 12 && navigationalStatus == "MOORED"`, true},
+		{`lastPositionUpdate.speed < 12`, false},
+		{`shipType in ["TANKER", "CARGO"] && mmsi == 244660000`, true},
+		{`staticData.dimensions.length > 100.0`, false},
+		{`id.startsWith("b")`, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(t *testing.T) {
+			is := is.New(t)
+			f, err := newNodeFilter(tc.expression, nil)
+			is.NoErr(err)
+			got, err := f.Match(moored, nil)
+			is.NoErr(err)
+			is.Equal(got, tc.want)
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		is := is.New(t)
+		for _, expression := range []string{
+			`speed >`,
+			`unknownField == 1`,
+			`speed + 1`,                 // not a bool
+			`timestamp == "2024-01-01"`, // ambiguous, only available with the object name
+		} {
+			_, err := newNodeFilter(expression, nil)
+			is.True(err != nil)
+		}
+	})
+
+	t.Run("Nullable", func(t *testing.T) {
+		is := is.New(t)
+		f, err := newNodeFilter(`speed == null || lastPositionUpdate.speed == null`, []string{"lastPositionUpdate.speed"})
+		is.NoErr(err)
+
+		got, err := f.Match(moored, []string{"lastPositionUpdate.speed"})
+		is.NoErr(err)
+		is.True(got)
+
+		got, err = f.Match(moored, nil)
+		is.NoErr(err)
+		is.True(!got)
+	})
+}
+
+func TestIterator_Filter(t *testing.T) {
+	unavailable := testVessel
+	unavailable.ID = "unavailable"
+	unavailable.LastPositionUpdate.Speed = 102.3
+	fast := testVessel
+	fast.ID = "fast"
+	fast.LastPositionUpdate.Speed = 20
+	slow := testVessel
+	slow.ID = "slow"
+	slow.LastPositionUpdate.Speed = 5
+
+	testCases := []struct {
+		name string
+		cfg  SourceConfig
+		want []string
+	}{{
+		name: "Filter",
+		cfg:  SourceConfig{Filter: `speed < 12.0`},
+		want: []string{"slow"},
+	}, {
+		name: "Exclude",
+		cfg:  SourceConfig{Exclude: `speed > 12.0`},
+		want: []string{"slow"},
+	}, {
+		// comparing the null speed fails, it matches neither expression
+		name: "NormalizedFilter",
+		cfg: SourceConfig{
+			Filter:    `speed < 12.0`,
+			Normalize: NormalizeConfig{Fields: []string{"lastPositionUpdate.speed"}},
+		},
+		want: []string{"slow"},
+	}, {
+		name: "NormalizedExclude",
+		cfg: SourceConfig{
+			Exclude:   `speed > 12.0`,
+			Normalize: NormalizeConfig{Fields: []string{"lastPositionUpdate.speed"}},
+		},
+		want: []string{"unavailable", "slow"},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			client := &MockGraphQLClient{
+				RunFn: func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+					arg := resp.(*struct{ Vessels Vessels })
+					arg.Vessels.Nodes = []Node{unavailable, fast, slow}
+					return nil
+				},
+			}
+			it, err := NewIterator(client, "test-token", vesselQuery(), 3, nil)
+			is.NoErr(err)
+			is.NoErr(it.applyConfig(tc.cfg))
+
+			var got []string
+			for range tc.want {
+				record, err := it.Next(context.Background())
+				is.NoErr(err)
+				got = append(got, string(record.Key.Bytes()))
+			}
+			is.Equal(got, tc.want)
+			is.Equal(it.nodesFiltered, 3-len(tc.want))
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"container/list"
+	"encoding/json"
+	"slices"
+)
+
+const (
+	PayloadFormatJSON    = "json"
+	PayloadFormatGeoJSON = "geojson"
+)
+
+type PayloadConfig struct {
+	// Format is the payload format: "json" is the vessel node as returned by
+	// the API, "geojson" a GeoJSON Feature with the position as geometry.
+	Format string `json:"format" default:"json" validate:"inclusion=json|geojson"`
+	// Track is the number of recent positions of a vessel included as a
+	// LineString in GeoJSON payloads. A value below 2 disables the track.
+	Track int `json:"track" default:"0"`
+	// TrackVessels is the number of vessels whose track is kept in memory.
+	// The track of the least recently updated vessel is dropped first, 0
+	// keeps all tracks.
+	TrackVessels int `json:"trackVessels" default:"10000"`
+	// Encoding is the encoding of "json" payloads: "json", or "protobuf" and
+	// "avro" binary using the schemas in the schema directory.
+	Encoding string `json:"encoding" default:"json" validate:"inclusion=json|protobuf|avro"`
+	// Envelope wraps the payload: "none" or "cloudevents" for a CloudEvents
+	// 1.0 event in the structured JSON format.
+	Envelope string `json:"envelope" default:"none" validate:"inclusion=none|cloudevents"`
+	// CloudEvents configures the CloudEvents envelope.
+	CloudEvents CloudEventsConfig `json:"cloudEvents"`
+}
+
+// geoJSONFeature is a GeoJSON Feature as defined in RFC 7946.
+type geoJSONFeature struct {
+	Type       string           `json:"type"`
+	ID         string           `json:"id,omitempty"`
+	Geometry   *geoJSONGeometry `json:"geometry"`
+	Properties map[string]any   `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string            `json:"type"`
+	Coordinates any               `json:"coordinates,omitempty"`
+	Geometries  []geoJSONGeometry `json:"geometries,omitempty"`
+}
+
+// geoJSONEncoder converts nodes to GeoJSON Features and keeps the recent
+// positions of the most recently updated vessels in memory.
+type geoJSONEncoder struct {
+	track   int
+	vessels int
+	tracks  map[string]*list.Element
+	// recent orders the tracks from the most to the least recently updated.
+	recent *list.List
+}
+
+// vesselTrack is the track of a vessel in the recently updated list.
+type vesselTrack struct {
+	id        string
+	positions [][2]float64
+}
+
+func newGeoJSONEncoder(track, vessels int) *geoJSONEncoder {
+	return &geoJSONEncoder{
+		track:   track,
+		vessels: vessels,
+		tracks:  make(map[string]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+// addPosition appends the position to the track of the vessel and returns
+// the track. The track of the least recently updated vessel is dropped if
+// more vessels are tracked than configured.
+func (e *geoJSONEncoder) addPosition(id string, pos [2]float64) [][2]float64 {
+	el, ok := e.tracks[id]
+	if ok {
+		e.recent.MoveToFront(el)
+	} else {
+		el = e.recent.PushFront(&vesselTrack{id: id})
+		e.tracks[id] = el
+	}
+	t := el.Value.(*vesselTrack)
+	t.positions = append(t.positions, pos)
+	if len(t.positions) > e.track {
+		t.positions = slices.Clone(t.positions[len(t.positions)-e.track:])
+	}
+
+	for e.vessels > 0 && e.recent.Len() > e.vessels {
+		oldest := e.recent.Back()
+		e.recent.Remove(oldest)
+		delete(e.tracks, oldest.Value.(*vesselTrack).id)
+	}
+	return t.positions
+}
+
+// Encode returns the node as a GeoJSON Feature with the document of the node
+// as properties. If a track is configured, the position is added to the track
+// of the vessel.
+func (e *geoJSONEncoder) Encode(n Node, doc map[string]any) ([]byte, error) {
+	f := e.feature(n, doc)
+
+	pos, ok := coordinates(n)
+	if ok && e.track >= 2 {
+		track := e.addPosition(n.ID, pos)
+		if len(track) >= 2 {
+			f.Geometry = &geoJSONGeometry{
+				Type: "GeometryCollection",
+				Geometries: []geoJSONGeometry{
+					*f.Geometry,
+					{Type: "LineString", Coordinates: track},
+				},
+			}
+		}
+	}
+	return json.Marshal(f)
+}
+
+// EncodeDeleted returns the last observation of a removed vessel as a
+// GeoJSON Feature and forgets its track.
+func (e *geoJSONEncoder) EncodeDeleted(n Node, doc map[string]any) ([]byte, error) {
+	if el, ok := e.tracks[n.ID]; ok {
+		e.recent.Remove(el)
+		delete(e.tracks, n.ID)
+	}
+	return json.Marshal(e.feature(n, doc))
+}
+
+// feature returns the node as a Feature with a Point geometry. The geometry
+// is null if the node has no valid position. The coordinates are removed from
+// the properties.
+func (e *geoJSONEncoder) feature(n Node, doc map[string]any) geoJSONFeature {
+	if lpu, ok := doc["lastPositionUpdate"].(map[string]any); ok {
+		delete(lpu, "latitude")
+		delete(lpu, "longitude")
+	}
+	f := geoJSONFeature{
+		Type:       "Feature",
+		ID:         n.ID,
+		Properties: doc,
+	}
+	if pos, ok := coordinates(n); ok {
+		f.Geometry = &geoJSONGeometry{Type: "Point", Coordinates: pos}
+	}
+	return f
+}
+
+// coordinates returns the position of the vessel as [longitude, latitude], the
+// coordinate order required by RFC 7946, and whether it is valid.
+func coordinates(n Node) ([2]float64, bool) {
+	lon, lat := n.LastPositionUpdate.Longitude, n.LastPositionUpdate.Latitude
+	if lon < -180 || lon > 180 || lat < -90 || lat > 90 || (lon == 0 && lat == 0) {
+		return [2]float64{}, false
+	}
+	return [2]float64{lon, lat}, true
+}
@@ -0,0 +1,121 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestGeoJSONEncoder(t *testing.T) {
+	vessel := func(lon, lat float64) Node {
+		return Node{
+			ID:                 "vessel-1",
+			StaticData:         StaticData{MMSI: 244660000, Name: "ALMA"},
+			LastPositionUpdate: LastPositionUpdate{Longitude: lon, Latitude: lat, Speed: 12.5},
+		}
+	}
+	encode := func(is *is.I, e *geoJSONEncoder, n Node) []byte {
+		doc, err := nodeDocument(n)
+		is.NoErr(err)
+		b, err := e.Encode(n, doc)
+		is.NoErr(err)
+		return b
+	}
+	decode := func(is *is.I, b []byte) map[string]any {
+		var f map[string]any
+		is.NoErr(json.Unmarshal(b, &f))
+		return f
+	}
+
+	t.Run("Point", func(t *testing.T) {
+		is := is.New(t)
+		f := decode(is, encode(is, newGeoJSONEncoder(0, 0), vessel(4.89, 52.37)))
+		is.Equal(f["type"], "Feature")
+		is.Equal(f["id"], "vessel-1")
+		is.Equal(f["geometry"], map[string]any{
+			"type":        "Point",
+			"coordinates": []any{4.89, 52.37}, // longitude first
+		})
+
+		props := f["properties"].(map[string]any)
+		is.Equal(props["staticData"].(map[string]any)["mmsi"], float64(244660000))
+		lpu := props["lastPositionUpdate"].(map[string]any)
+		is.Equal(lpu["speed"], 12.5)
+		_, ok := lpu["latitude"]
+		is.True(!ok)
+		_, ok = lpu["longitude"]
+		is.True(!ok)
+	})
+
+	t.Run("InvalidPosition", func(t *testing.T) {
+		is := is.New(t)
+		e := newGeoJSONEncoder(3, 0)
+		for _, n := range []Node{vessel(0, 0), vessel(181, 10), vessel(10, -91)} {
+			f := decode(is, encode(is, e, n))
+			g, ok := f["geometry"]
+			is.True(ok)
+			is.Equal(g, nil)
+		}
+		is.Equal(len(e.tracks), 0)
+	})
+
+	t.Run("Track", func(t *testing.T) {
+		is := is.New(t)
+		e := newGeoJSONEncoder(2, 0)
+
+		b := encode(is, e, vessel(1, 50))
+		is.Equal(decode(is, b)["geometry"].(map[string]any)["type"], "Point")
+
+		encode(is, e, vessel(2, 51))
+		b = encode(is, e, vessel(3, 52))
+		is.Equal(decode(is, b)["geometry"], map[string]any{
+			"type": "GeometryCollection",
+			"geometries": []any{
+				map[string]any{"type": "Point", "coordinates": []any{3.0, 52.0}},
+				map[string]any{"type": "LineString", "coordinates": []any{
+					[]any{2.0, 51.0},
+					[]any{3.0, 52.0},
+				}},
+			},
+		})
+
+		doc, err := nodeDocument(vessel(3, 52))
+		is.NoErr(err)
+		b, err = e.EncodeDeleted(vessel(3, 52), doc)
+		is.NoErr(err)
+		is.Equal(decode(is, b)["geometry"].(map[string]any)["type"], "Point")
+		is.Equal(len(e.tracks), 0)
+	})
+
+	t.Run("TrackVessels", func(t *testing.T) {
+		is := is.New(t)
+		e := newGeoJSONEncoder(2, 2)
+
+		for _, id := range []string{"a", "b", "a", "c"} {
+			n := vessel(1, 50)
+			n.ID = id
+			encode(is, e, n)
+		}
+		// b was updated least recently
+		is.Equal(len(e.tracks), 2)
+		is.Equal(e.recent.Len(), 2)
+		_, ok := e.tracks["b"]
+		is.True(!ok)
+		is.Equal(len(e.tracks["a"].Value.(*vesselTrack).positions), 2)
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import "math"
+
+// earthRadius is the mean radius of the earth in meters.
+const earthRadius = 6371008.8
+
+// vesselGeometry describes the hull of a vessel derived from the AIS
+// dimensions, which are the distances of the position antenna to the bow (A),
+// stern (B), port (C) and starboard (D) in meters.
+type vesselGeometry struct {
+	// Length is the overall length, A+B.
+	Length float64 `json:"length"`
+	// Beam is the overall width, C+D.
+	Beam float64 `json:"beam"`
+	// AntennaOffset is the position of the antenna relative to the center of
+	// the hull.
+	AntennaOffset antennaOffset `json:"antennaOffset"`
+	// Hull is the outline of the hull around the reported position, oriented
+	// by the heading or, if not available, the course.
+	Hull *geoJSONGeometry `json:"hull,omitempty"`
+}
+
+type antennaOffset struct {
+	// Forward is the distance of the antenna ahead of the center, negative if
+	// it is behind the center.
+	Forward float64 `json:"forward"`
+	// Starboard is the distance of the antenna to starboard of the center,
+	// negative if it is to port.
+	Starboard float64 `json:"starboard"`
+}
+
+// deriveGeometry returns the geometry of the vessel, or nil if the dimensions
+// were not reported. The hull polygon is only computed if hull is set and the
+// vessel has a valid position and orientation.
+func deriveGeometry(n Node, hull bool) *vesselGeometry {
+	d := n.StaticData.Dimensions
+	if d.A+d.B == 0 || d.C+d.D == 0 {
+		return nil
+	}
+
+	g := &vesselGeometry{
+		Length: d.A + d.B,
+		Beam:   d.C + d.D,
+		AntennaOffset: antennaOffset{
+			Forward:   (d.B - d.A) / 2,
+			Starboard: (d.C - d.D) / 2,
+		},
+	}
+	if !hull {
+		return g
+	}
+	pos, ok := coordinates(n)
+	if !ok {
+		return g
+	}
+	bearing, ok := orientation(n.LastPositionUpdate)
+	if !ok {
+		return g
+	}
+
+	// corners relative to the antenna as (forward, starboard), ordered so
+	// the ring is counterclockwise as required by RFC 7946
+	corners := [][2]float64{{d.A, -d.C}, {-d.B, -d.C}, {-d.B, d.D}, {d.A, d.D}}
+	ring := make([][2]float64, 0, len(corners)+1)
+	for _, c := range corners {
+		ring = append(ring, offsetPosition(pos, bearing, c[0], c[1]))
+	}
+	ring = append(ring, ring[0])
+	g.Hull = &geoJSONGeometry{Type: "Polygon", Coordinates: [][][2]float64{ring}}
+	return g
+}
+
+// orientation returns the heading of the vessel in degrees or, if it is not
+// available, the course over ground.
+func orientation(p LastPositionUpdate) (float64, bool) {
+	if p.Heading >= 0 && p.Heading < 360 {
+		return p.Heading, true
+	}
+	if p.Course >= 0 && p.Course < 360 {
+		return p.Course, true
+	}
+	return 0, false
+}
+
+// offsetPosition moves the [longitude, latitude] position by the given number
+// of meters forward and to starboard of a vessel with the given bearing. It
+// uses an equirectangular approximation, which is accurate at hull scale.
+func offsetPosition(pos [2]float64, bearing, forward, starboard float64) [2]float64 {
+	theta := bearing * math.Pi / 180
+	north := forward*math.Cos(theta) - starboard*math.Sin(theta)
+	east := forward*math.Sin(theta) + starboard*math.Cos(theta)
+
+	lat := pos[1] * math.Pi / 180
+	return [2]float64{
+		pos[0] + east/(earthRadius*math.Cos(lat))*180/math.Pi,
+		pos[1] + north/earthRadius*180/math.Pi,
+	}
+}
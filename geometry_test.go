@@ -0,0 +1,101 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"math"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestDeriveGeometry(t *testing.T) {
+	vessel := func(heading, course float64) Node {
+		return Node{
+			StaticData: StaticData{Dimensions: Dimensions{A: 100, B: 50, C: 10, D: 20}},
+			LastPositionUpdate: LastPositionUpdate{
+				Longitude: 4.89,
+				Latitude:  52.37,
+				Heading:   heading,
+				Course:    course,
+			},
+		}
+	}
+	// meters returns the distance of the positions in meters along the north
+	// and east axes
+	meters := func(from, to [2]float64) (north, east float64) {
+		north = (to[1] - from[1]) * math.Pi / 180 * earthRadius
+		east = (to[0] - from[0]) * math.Pi / 180 * earthRadius * math.Cos(from[1]*math.Pi/180)
+		return north, east
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 0.01 }
+
+	t.Run("Dimensions", func(t *testing.T) {
+		is := is.New(t)
+		g := deriveGeometry(vessel(0, 0), false)
+		is.Equal(g.Length, 150.0)
+		is.Equal(g.Beam, 30.0)
+		is.Equal(g.AntennaOffset, antennaOffset{Forward: -25, Starboard: -5})
+		is.Equal(g.Hull, nil)
+
+		is.Equal(deriveGeometry(Node{}, true), nil)
+	})
+
+	t.Run("Hull", func(t *testing.T) {
+		is := is.New(t)
+		n := vessel(90, 0) // heading east
+		pos, _ := coordinates(n)
+		g := deriveGeometry(n, true)
+		is.Equal(g.Hull.Type, "Polygon")
+
+		ring := g.Hull.Coordinates.([][][2]float64)[0]
+		is.Equal(len(ring), 5)
+		is.Equal(ring[0], ring[4])
+
+		// the bow is east of the antenna, port is north
+		north, east := meters(pos, ring[0])
+		is.True(near(north, 10))
+		is.True(near(east, 100))
+		north, east = meters(pos, ring[2])
+		is.True(near(north, -20))
+		is.True(near(east, -50))
+
+		// exterior rings are counterclockwise
+		area := 0.0
+		for i := 0; i < len(ring)-1; i++ {
+			area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+		}
+		is.True(area > 0)
+	})
+
+	t.Run("CourseFallback", func(t *testing.T) {
+		is := is.New(t)
+		n := vessel(511, 180) // heading not available, moving south
+		pos, _ := coordinates(n)
+		ring := deriveGeometry(n, true).Hull.Coordinates.([][][2]float64)[0]
+		north, east := meters(pos, ring[0])
+		is.True(near(north, -100))
+		is.True(near(east, 10))
+
+		n.LastPositionUpdate.Course = 360
+		is.Equal(deriveGeometry(n, true).Hull, nil)
+	})
+}
+
+func TestDeriveConfig_Validate(t *testing.T) {
+	is := is.New(t)
+	is.NoErr(DeriveConfig{Geometry: true, HullPolygon: true}.validate())
+	is.True(DeriveConfig{HullPolygon: true}.validate() != nil)
+}
@@ -18,10 +18,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/conduitio/conduit-commons/opencdc"
 	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/google/uuid"
 	"github.com/machinebox/graphql"
 )
 
@@ -46,9 +48,97 @@ type Iterator struct {
 	client         GraphQLClient
 	currentBatch   []Node
 	nodesProcessed int
+	store          *vesselStore
+	changeTracking ChangeTrackingConfig
+	staleAfter     time.Duration
+	// tombstones contains stale vessels for which delete records are emitted
+	// before any further nodes.
+	tombstones []vesselState
+
+	// pageCursor is the cursor the current page was requested with and
+	// pageOffset the index of the next node within that page.
+	pageCursor string
+	pageOffset int
+	// skip is the number of nodes to drop from the next page when resuming
+	// from a position in the middle of a page.
+	skip      int
+	watermark time.Time
+	// startTime is the lower bound for position updates of the current
+	// result set. Every complete result set moves it to the watermark.
+	startTime time.Time
+	// windowEnd is the exclusive upper bound of the current backfill window,
+	// it is zero when no backfill is in progress.
+	windowEnd time.Time
+	backfill  backfill
+
+	checkpoints *checkpointer
+	statePath   string
+
+	renderer      *queryRenderer
+	queryFiles    *queryFiles
+	queryVars     map[string]string
+	operationName string
+
+	// static caches the static vessel data in tiered polling mode.
+	static *staticCache
+
+	// schedule determines when the next result set is requested, it is nil
+	// if the pacing is left to the SDK's backoff.
+	schedule     *pollSchedule
+	nextPoll     time.Time
+	sweepStarted time.Time
+	// sweepNodes is the number of nodes returned in the current result set.
+	sweepNodes int
+
+	watchlist *watchlist
+	// watched contains the nodes of watched vessels that are emitted before
+	// any further nodes of the regular result set.
+	watched []watchedNode
+
+	// tombstoneSeq is the number of delete records emitted for stale vessels.
+	tombstoneSeq int
+	// watchedSeq is the number of records emitted for watched vessels.
+	watchedSeq int
+
+	// endTime is the latest update timestamp of nodes that are emitted.
+	endTime        time.Time
+	sweeps         int
+	nodesFiltered  int
+	nodesMalformed int
+
+	// errors configures how nodes that cannot be converted to records are
+	// handled.
+	errors ErrorsConfig
+
+	// filter keeps the vessels it matches, exclude drops them.
+	filter  *nodeFilter
+	exclude *nodeFilter
+	keys    *keyBuilder
+	// collections is nil if records are not routed to collections.
+	collections *collectionRouter
+	// geojson is set if payloads are GeoJSON Features.
+	geojson *geoJSONEncoder
+	// encoder is set if payloads are encoded with a schema.
+	encoder schemaEncoder
+	// events is set if payloads are wrapped as CloudEvents.
+	events *cloudEventsEnvelope
+	// normalizer is set if sentinel values are normalized.
+	normalizer *normalizer
+	derive     DeriveConfig
+
+	// queryName identifies the query in the record metadata and page
+	// describes the response the current batch was read from.
+	queryName string
+	page      fetchedPage
 }
 
 func NewIterator(client GraphQLClient, token string, query string, batchSize int, p opencdc.Position) (*Iterator, error) {
+	pos, err := ParsePosition(p)
+	if err != nil {
+		return nil, err
+	}
+
+	store := newVesselStore()
 	return &Iterator{
 		token:          token,
 		query:          query,
@@ -56,15 +146,170 @@ func NewIterator(client GraphQLClient, token string, query string, batchSize int
 		client:         client,
 		position:       p,
 		nodesProcessed: 0,
+		store:          store,
+		cursor:         pos.Cursor,
+		hasNext:        pos.Cursor != "",
+		skip:           pos.Offset + 1,
+		watermark:      pos.Watermark,
+		startTime:      pos.StartTime,
+		windowEnd:      pos.WindowEnd,
+		checkpoints:    newCheckpointer(pos, store.Clone()),
 	}, nil
 }
 
+// applyConfig applies the optional source settings to the iterator.
+func (it *Iterator) applyConfig(cfg SourceConfig) error {
+	it.changeTracking = cfg.ChangeTracking
+	it.staleAfter = cfg.StaleAfter
+	it.errors = cfg.Errors
+	it.derive = cfg.Derive
+	it.statePath = cfg.StatePath
+	it.queryVars = cfg.QueryVariables
+	it.operationName = cfg.OperationName
+	it.queryName = queryName(cfg)
+
+	if cfg.QueryFile != "" {
+		it.queryFiles = newQueryFiles(cfg.QueryFile, cfg.FragmentFiles)
+		query, err := it.queryFiles.Load()
+		if err != nil {
+			return err
+		}
+		it.query = query
+	}
+	renderer, err := newQueryRenderer(it.query, it.queryVars, it.operationName, it.queryFiles != nil)
+	if err != nil {
+		return err
+	}
+	it.renderer = renderer
+
+	if cfg.TieredPolling.Enabled {
+		static, err := newStaticCache(cfg)
+		if err != nil {
+			return err
+		}
+		it.static = static
+	}
+
+	if cfg.Key.Strategy != "" {
+		keys, err := newKeyBuilder(cfg.Key)
+		if err != nil {
+			return err
+		}
+		it.keys = keys
+	}
+
+	if cfg.Payload.Format == PayloadFormatGeoJSON {
+		it.geojson = newGeoJSONEncoder(cfg.Payload.Track, cfg.Payload.TrackVessels)
+	}
+
+	if cfg.Payload.Encoding != "" && cfg.Payload.Encoding != PayloadEncodingJSON {
+		encoder, err := newSchemaEncoder(cfg.Payload.Encoding)
+		if err != nil {
+			return err
+		}
+		it.encoder = encoder
+	}
+
+	if len(cfg.Normalize.Fields) > 0 {
+		normalizer, err := newNormalizer(cfg.Normalize)
+		if err != nil {
+			return err
+		}
+		it.normalizer = normalizer
+	}
+
+	if cfg.Payload.Envelope == PayloadEnvelopeCloudEvents {
+		it.events = newCloudEventsEnvelope(cfg)
+	}
+
+	if cfg.Collection.Template != "" || cfg.Collection.Default != "" {
+		collections, err := newCollectionRouter(cfg.Collection)
+		if err != nil {
+			return err
+		}
+		it.collections = collections
+	}
+
+	if cfg.Filter != "" {
+		filter, err := newNodeFilter(cfg.Filter, it.normalizer.nullable())
+		if err != nil {
+			return err
+		}
+		it.filter = filter
+	}
+
+	if cfg.Exclude != "" {
+		exclude, err := newNodeFilter(cfg.Exclude, it.normalizer.nullable())
+		if err != nil {
+			return err
+		}
+		it.exclude = exclude
+	}
+
+	if cfg.Watchlist.Path != "" {
+		w, err := newWatchlist(cfg)
+		if err != nil {
+			return err
+		}
+		it.watchlist = w
+	}
+
+	// run once mode never polls again
+	if !cfg.RunOnce.Enabled {
+		schedule, err := newPollSchedule(cfg.Poll)
+		if err != nil {
+			return err
+		}
+		it.schedule = schedule
+	}
+
+	if cfg.Backfill.Enabled {
+		b, err := newBackfill(cfg.Backfill, time.Now())
+		if err != nil {
+			return err
+		}
+		it.backfill = b
+		// only start a new backfill if there is no saved position, otherwise
+		// the position tells whether the backfill is still in progress
+		if it.startTime.IsZero() {
+			it.startTime = b.start
+			it.windowEnd = b.nextWindowEnd(b.start)
+		}
+	}
+
+	if it.startTime.IsZero() && cfg.StartFrom != "" {
+		startTime, err := parseStartFrom(cfg.StartFrom, time.Now())
+		if err != nil {
+			return err
+		}
+		it.startTime = startTime
+	}
+
+	if cfg.RunOnce.EndTime != "" {
+		endTime, err := time.Parse(time.RFC3339, cfg.RunOnce.EndTime)
+		if err != nil {
+			return fmt.Errorf("invalid end time: %w", err)
+		}
+		it.endTime = endTime
+	}
+
+	if it.statePath != "" {
+		store, err := loadVesselStore(it.statePath)
+		if err != nil {
+			return err
+		}
+		it.store = store
+		it.checkpoints = newCheckpointer(it.checkpoints.Committed(), store.Clone())
+	}
+	return nil
+}
+
 // Ensure Iterator implements IteratorInterface
 var _ IteratorInterface = (*Iterator)(nil)
 
 func (it *Iterator) HasNext(ctx context.Context) bool {
-	// return early if there are more nodes
-	if len(it.currentBatch) > 0 {
+	// return early if there are more records
+	if len(it.tombstones) > 0 || len(it.watched) > 0 || len(it.currentBatch) > 0 {
 		return true
 	}
 
@@ -77,43 +322,492 @@ func (it *Iterator) HasNext(ctx context.Context) bool {
 		return true
 	}
 
-	return false
+	// the next backfill window is loaded by Next
+	return !it.windowEnd.IsZero()
 }
 
 func (it *Iterator) Next(ctx context.Context) (opencdc.Record, error) {
-	// return next message from cached batch
-	var out Node
-	if len(it.currentBatch) > 0 {
+	for {
+		if len(it.tombstones) > 0 {
+			return it.nextTombstone()
+		}
+
+		if err := it.pollWatchlist(ctx); err != nil {
+			return opencdc.Record{}, err
+		}
+		if len(it.watched) > 0 {
+			record, ok, err := it.nextWatched(ctx)
+			if err != nil || ok {
+				return record, err
+			}
+			continue // the node was malformed and skipped
+		}
+
+		// return next message from cached batch
+		if len(it.currentBatch) == 0 {
+			if !it.hasNext {
+				due, err := it.waitForPoll(ctx)
+				if err != nil {
+					return opencdc.Record{}, fmt.Errorf("error waiting for next poll: %w", err)
+				}
+				if !due {
+					continue // the watchlist is due first
+				}
+			}
+			err := it.loadBatch(ctx)
+			if err != nil {
+				sdk.Logger(ctx).Err(err).Msg("loadBatch returned error")
+				return opencdc.Record{}, fmt.Errorf("loadBatch returned error: %w", err)
+			}
+			if len(it.currentBatch) == 0 {
+				if !it.hasNext {
+					it.endSweep(ctx)
+					if len(it.tombstones) > 0 || !it.windowEnd.IsZero() || it.schedule != nil {
+						continue
+					}
+				}
+				return opencdc.Record{}, fmt.Errorf("no nodes returned: %w", sdk.ErrBackoffRetry)
+			}
+		}
+
+		var out Node
 		out, it.currentBatch = it.currentBatch[0], it.currentBatch[1:]
-	} else {
-		err := it.loadBatch(ctx)
+		it.nodesProcessed++
+		offset := it.pageOffset
+		it.pageOffset++
+		last := len(it.currentBatch) == 0 && !it.hasNext
+
+		if it.static != nil {
+			out = it.static.merge(out)
+		}
+		if !it.accept(ctx, out) {
+			it.nodesFiltered++
+			if !last {
+				continue
+			}
+			it.endSweep(ctx)
+			if len(it.tombstones) > 0 || !it.windowEnd.IsZero() || it.schedule != nil {
+				continue
+			}
+			return opencdc.Record{}, fmt.Errorf("no nodes left: %w", sdk.ErrBackoffRetry)
+		}
+
+		if ts, err := time.Parse(time.RFC3339, out.UpdateTimestamp); err == nil && ts.After(it.watermark) {
+			it.watermark = ts
+		}
+		it.position = Position{
+			Cursor:    it.pageCursor,
+			Offset:    offset,
+			Watermark: it.watermark,
+			StartTime: it.startTime,
+			WindowEnd: it.windowEnd,
+		}.ToRecordPosition()
+
+		record, err := it.wrap(out, it.page)
 		if err != nil {
-			sdk.Logger(ctx).Err(err).Msg("loadBatch returned error")
-			return opencdc.Record{}, fmt.Errorf("loadBatch returned error: %w", err)
+			dead, ok, err := it.malformed(ctx, out, it.page, err)
+			if err != nil {
+				return opencdc.Record{}, err
+			}
+			if last {
+				it.endSweep(ctx)
+			}
+			if ok {
+				return dead, nil
+			}
+			if !last || len(it.tombstones) > 0 || !it.windowEnd.IsZero() || it.schedule != nil {
+				continue
+			}
+			return opencdc.Record{}, fmt.Errorf("no nodes left: %w", sdk.ErrBackoffRetry)
 		}
-		out, it.currentBatch = it.currentBatch[0], it.currentBatch[1:]
+		if err := it.trackChanges(out, record.Metadata); err != nil {
+			return opencdc.Record{}, err
+		}
+		if last {
+			it.endSweep(ctx)
+		}
+		return record, nil
+	}
+}
+
+// accept reports whether a node should be emitted as a record.
+func (it *Iterator) accept(ctx context.Context, n Node) bool {
+	if !it.endTime.IsZero() {
+		ts, err := time.Parse(time.RFC3339, n.UpdateTimestamp)
+		if err == nil && ts.After(it.endTime) {
+			return false
+		}
+	}
+	if it.filter == nil && it.exclude == nil {
+		return true
+	}
+
+	var null []string
+	if it.normalizer != nil {
+		null = it.normalizer.Normalize(n)
+	}
+	if it.filter != nil && !it.matches(ctx, it.filter, n, null) {
+		return false
+	}
+	return it.exclude == nil || !it.matches(ctx, it.exclude, n, null)
+}
+
+// matches reports whether the expression is true for the node. An expression
+// that can't be evaluated for the node, e.g. because it compares a null
+// field, does not match.
+func (it *Iterator) matches(ctx context.Context, f *nodeFilter, n Node, null []string) bool {
+	match, err := f.Match(n, null)
+	if err != nil {
+		sdk.Logger(ctx).Debug().Err(err).Str("id", n.ID).Msg("expression could not be evaluated, treating it as no match")
+		return false
+	}
+	return match
+}
+
+func (it *Iterator) nextTombstone() (opencdc.Record, error) {
+	var v vesselState
+	v, it.tombstones = it.tombstones[0], it.tombstones[1:]
+
+	// delete records follow a complete result set, resuming from their
+	// position starts the next one
+	it.tombstoneSeq++
+	position := Position{
+		Offset:    -1,
+		Watermark: it.watermark,
+		StartTime: it.startTime,
+		WindowEnd: it.windowEnd,
+		Tombstone: it.tombstoneSeq,
+	}.ToRecordPosition()
+	it.position = position
+
+	record, err := wrapAsTombstone(v.Node, position)
+	if err != nil {
+		return opencdc.Record{}, err
+	}
+	if it.keys != nil {
+		record.Key = it.keys.Key(v.Node)
+	}
+	setVesselMetadata(v.Node, record.Metadata)
+	it.setCollection(v.Node, record.Metadata)
+	if it.customPayload() {
+		b, err := it.encodePayload(v.Node, record.Metadata, true)
+		if err != nil {
+			return opencdc.Record{}, err
+		}
+		record.Payload.Before = opencdc.RawData(b)
+	}
+	it.checkpoints.Track(record.Position, v.Node, v.LastSeen, true)
+	return record, nil
+}
+
+// wrap creates the record of a node read from page at the current position.
+func (it *Iterator) wrap(n Node, page fetchedPage) (opencdc.Record, error) {
+	record, err := wrapAsRecord(n, it.position)
+	if err != nil {
+		return opencdc.Record{}, err
 	}
-	it.nodesProcessed++
-	return wrapAsRecord(out, it.position)
+	setVesselMetadata(n, record.Metadata)
+	setPageMetadata(page, record.Metadata)
+	it.setCollection(n, record.Metadata)
+	if it.customPayload() {
+		b, err := it.encodePayload(n, record.Metadata, false)
+		if err != nil {
+			return opencdc.Record{}, err
+		}
+		record.Payload.After = opencdc.RawData(b)
+	}
+	if it.keys != nil {
+		record.Key = it.keys.Key(n)
+	}
+	if it.watchlist != nil {
+		it.watchlist.annotate(n, record.Metadata)
+	}
+	return record, nil
+}
+
+// customPayload reports whether payloads differ from the node as returned by
+// the API.
+func (it *Iterator) customPayload() bool {
+	return it.normalizer != nil || it.derive.enabled() || it.geojson != nil || it.encoder != nil || it.events != nil
+}
+
+// encodePayload returns the payload of the record of a vessel in the
+// configured format, encoding and envelope.
+func (it *Iterator) encodePayload(n Node, metadata opencdc.Metadata, deleted bool) ([]byte, error) {
+	derived, err := it.derive.derive(n)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized []string
+	if it.normalizer != nil {
+		normalized = it.normalizer.Normalize(n)
+		if len(normalized) > 0 {
+			metadata[MetadataNormalized] = strings.Join(normalized, ",")
+		}
+	}
+
+	var b []byte
+	switch {
+	case it.encoder != nil:
+		b, err = it.encoder.Encode(n)
+		setSchemaMetadata(it.encoder, metadata)
+	case it.geojson != nil || len(normalized) > 0 || it.derive.enabled():
+		var doc map[string]any
+		doc, err = nodeDocument(n)
+		if err != nil {
+			return nil, err
+		}
+		if it.normalizer != nil {
+			it.normalizer.Clear(doc, normalized)
+		}
+		if it.derive.enabled() {
+			doc[derivedKey] = derived
+		}
+		switch {
+		case it.geojson == nil:
+			b, err = json.Marshal(doc)
+		case deleted:
+			b, err = it.geojson.EncodeDeleted(n, doc)
+		default:
+			b, err = it.geojson.Encode(n, doc)
+		}
+	default:
+		b, err = json.Marshal(n)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error occurred encoding payload: %w", err)
+	}
+
+	if it.events != nil {
+		return it.events.Wrap(n, b, deleted)
+	}
+	return b, nil
+}
+
+// Ack marks the record with the given position as processed. Once all records
+// up to it are processed, the committed position and vessel state advance.
+func (it *Iterator) Ack(ctx context.Context, position opencdc.Position) error {
+	advanced, err := it.checkpoints.Ack(position)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return nil
+	}
+
+	committed := it.checkpoints.Committed()
+	sdk.Logger(ctx).Debug().
+		Str("cursor", committed.Cursor).
+		Int("offset", committed.Offset).
+		Time("watermark", committed.Watermark).
+		Msg("committed position advanced")
+	return it.checkpoints.Flush(it.statePath, stateFlushInterval)
+}
+
+// Flush writes the committed vessel state if a state path is configured.
+func (it *Iterator) Flush() error {
+	return it.checkpoints.Flush(it.statePath, 0)
+}
+
+// endSweep is called once the last node of a complete result set was read. It
+// queues delete records for vessels that were not seen within staleAfter.
+func (it *Iterator) endSweep(ctx context.Context) {
+	if !it.windowEnd.IsZero() {
+		// continue with the next backfill window
+		it.startTime = it.windowEnd
+		if it.windowEnd.Before(it.backfill.end) {
+			it.windowEnd = it.backfill.nextWindowEnd(it.startTime)
+			return
+		}
+		it.windowEnd = time.Time{}
+		sdk.Logger(ctx).Info().Time("end", it.backfill.end).Msg("Backfill complete")
+	}
+
+	it.sweeps++
+	sdk.Logger(ctx).Info().
+		Int("sweeps", it.sweeps).
+		Int("nodesProcessed", it.nodesProcessed).
+		Int("nodesFiltered", it.nodesFiltered).
+		Int("nodesMalformed", it.nodesMalformed).
+		Msg("Result set complete")
+	if it.watermark.After(it.startTime) {
+		it.startTime = it.watermark
+	}
+	it.schedulePoll(ctx)
+	it.sweepNodes = 0
+	if it.staleAfter <= 0 {
+		return
+	}
+
+	it.tombstones = it.store.Expire(time.Now().Add(-it.staleAfter))
+	if len(it.tombstones) > 0 {
+		sdk.Logger(ctx).Info().Msgf("Stale vessels removed: %d", len(it.tombstones))
+	}
+}
+
+// trackChanges compares the node with the previously stored observation of
+// the same vessel, attaches the changes to the metadata if change tracking is
+// enabled and stores the node as the latest observation.
+func (it *Iterator) trackChanges(n Node, metadata opencdc.Metadata) error {
+	now := time.Now()
+	prev, seen := it.store.Get(n.ID)
+	it.store.Put(n, now)
+	it.checkpoints.Track(it.position, n, now, false)
+
+	if !it.changeTracking.Enabled || !seen {
+		return nil
+	}
+
+	changed, err := changedFields(prev.Node, n, it.changeTracking.IgnoreFields)
+	if err != nil {
+		return fmt.Errorf("error comparing vessel %q with previous state: %w", n.ID, err)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	metadata[MetadataChanged] = strings.Join(changed, ",")
+
+	if it.changeTracking.Patch {
+		patch, err := changePatch(n, changed)
+		if err != nil {
+			return fmt.Errorf("error creating patch for vessel %q: %w", n.ID, err)
+		}
+		metadata[MetadataPatch] = string(patch)
+	}
+	return nil
 }
 
 // Updated loadBatch function with dependency injection
 func (it *Iterator) loadBatch(ctx context.Context) error {
-	graphqlRequest := graphql.NewRequest(it.query)
+	after := ""
+	if it.hasNext {
+		after = it.cursor
+	}
+	if after == "" {
+		it.sweepStarted = time.Now()
+	}
+
+	vessels, page, err := it.fetchPage(ctx, after)
+	for err == nil && it.splitWindow(ctx, after, vessels.TotalCount) {
+		vessels, page, err = it.fetchPage(ctx, after)
+	}
+	if err != nil {
+		return err
+	}
+	if err := it.refreshStatic(ctx, vessels.Nodes); err != nil {
+		return err
+	}
+	page.query = it.queryName
+	page.cursor = after
+	page.number = 1
+	if after != "" {
+		page.number = it.page.number + 1
+	}
+	it.page = page
+
+	// fmt.Printf("GraphQL Response: %+v", Response)
+	sdk.Logger(context.Background()).Info().Msgf("GraphQL Response length: %+v", len(vessels.Nodes))
+	// sdk.Logger(ctx).Debug().Str("position", string(position)).Msg("got ack")
+
+	sdk.Logger(context.Background()).Info().Msgf("GraphQL Response: %d", vessels.TotalCount.Value)
+	// when resuming in the middle of a page, drop the nodes that were
+	// already read
+	offset := min(it.skip, len(vessels.Nodes))
+	it.skip = 0
+
+	it.sweepNodes += len(vessels.Nodes)
+	it.currentBatch = vessels.Nodes[offset:]
+	it.hasNext = vessels.PageInfo.HasNextPage
+	it.cursor = vessels.PageInfo.EndCursor
+	it.pageCursor = after
+	it.pageOffset = offset
+
+	return nil
+}
+
+// reloadQuery reloads the query if any of the query files changed. An invalid
+// query is logged and the previous one is kept.
+func (it *Iterator) reloadQuery(ctx context.Context) {
+	if it.queryFiles == nil {
+		return
+	}
+	changed, err := it.queryFiles.Changed()
+	if err != nil {
+		sdk.Logger(ctx).Err(err).Msg("failed to check query files, keeping the current query")
+		return
+	}
+	if !changed {
+		return
+	}
+
+	query, err := it.queryFiles.Load()
+	if err == nil {
+		var renderer *queryRenderer
+		renderer, err = newQueryRenderer(query, it.queryVars, it.operationName, true)
+		if err == nil {
+			it.query, it.renderer = query, renderer
+			sdk.Logger(ctx).Info().Str("queryFile", it.queryFiles.queryFile).Msg("Query reloaded")
+			return
+		}
+	}
+	sdk.Logger(ctx).Err(err).Msg("failed to reload query, keeping the current query")
+}
+
+// fetchPage requests the page following the after cursor, retrying failed
+// requests.
+func (it *Iterator) fetchPage(ctx context.Context, after string) (Vessels, fetchedPage, error) {
+	it.reloadQuery(ctx)
+	query := it.query
+	if it.renderer != nil {
+		var err error
+		query, err = it.renderer.Render(QueryData{
+			Watermark:   it.watermark,
+			Now:         time.Now(),
+			WindowStart: it.startTime,
+			WindowEnd:   it.windowEnd,
+		})
+		if err != nil {
+			return Vessels{}, fetchedPage{}, err
+		}
+	}
+
+	graphqlRequest := graphql.NewRequest(query)
 	graphqlRequest.Header.Set("Authorization", fmt.Sprintf("Bearer %s", it.token))
 	graphqlRequest.Var("first", it.batchSize)
-	// currentTimestamp := time.Now().Add(-1 * time.Hour).Format(time.RFC3339)
-	// graphqlRequest.Var("startTime", currentTimestamp)
-	var Response struct {
-		Vessels Vessels
+	if !it.startTime.IsZero() {
+		graphqlRequest.Var("startTime", it.startTime.Format(time.RFC3339Nano))
+	} else if declaresVariable(query, "startTime") {
+		// the default query requires a start time
+		graphqlRequest.Var("startTime", earliestStart.Format(time.RFC3339Nano))
+	}
+	if !it.windowEnd.IsZero() {
+		graphqlRequest.Var("endTime", it.windowEnd.Format(time.RFC3339Nano))
 	}
-
 	lastSuccessfulCursor := it.cursor
 
-	if it.hasNext {
-		graphqlRequest.Var("after", it.cursor)
+	if after != "" {
+		graphqlRequest.Var("after", after)
+	}
+
+	vessels, page, err := it.runQuery(ctx, graphqlRequest)
+	if err != nil {
+		it.cursor = lastSuccessfulCursor
+		return Vessels{}, fetchedPage{}, err
+	}
+	return vessels, page, nil
+}
+
+// runQuery runs a vessels query, retrying failed requests. Every request is
+// sent with a generated ID, which is returned with the page.
+func (it *Iterator) runQuery(ctx context.Context, graphqlRequest *graphql.Request) (Vessels, fetchedPage, error) {
+	var Response struct {
+		Vessels Vessels
 	}
 
+	requestID := uuid.NewString()
+	graphqlRequest.Header.Set(requestIDHeader, requestID)
+
 	maxRetries := 3
 	retryDelay := time.Second * 2
 
@@ -123,27 +817,26 @@ func (it *Iterator) loadBatch(ctx context.Context) error {
 			break
 		}
 
+		if ctx.Err() != nil {
+			return Vessels{}, fetchedPage{}, fmt.Errorf("error making graphQL Request: %w", ctx.Err())
+		}
+
 		if i < maxRetries-1 {
-			sdk.Logger(context.Background()).Err(err).Msg("Retrying query...")
-			time.Sleep(retryDelay)
+			sdk.Logger(context.Background()).Err(err).Str("requestId", requestID).Msg("Retrying query...")
+			if err := sleep(ctx, retryDelay); err != nil {
+				return Vessels{}, fetchedPage{}, fmt.Errorf("error making graphQL Request: %w", err)
+			}
 		} else {
-			sdk.Logger(context.Background()).Err(err).Msg("%w")
-			it.cursor = lastSuccessfulCursor
-			return fmt.Errorf("error making graphQL Request: %w", err)
+			sdk.Logger(context.Background()).Err(err).Str("requestId", requestID).Msg("%w")
+			return Vessels{}, fetchedPage{}, fmt.Errorf("error making graphQL Request: %w", err)
 		}
 	}
 
-	// fmt.Printf("GraphQL Response: %+v", Response)
-	sdk.Logger(context.Background()).Info().Msgf("GraphQL Response length: %+v", len(Response.Vessels.Nodes))
-	// sdk.Logger(ctx).Debug().Str("position", string(position)).Msg("got ack")
-
-	sdk.Logger(context.Background()).Info().Msgf("GraphQL Response: %d", Response.Vessels.TotalCount.Value)
-	it.currentBatch = Response.Vessels.Nodes
-	it.hasNext = Response.Vessels.PageInfo.HasNextPage
-	it.cursor = Response.Vessels.PageInfo.EndCursor
-	it.position = []byte(Response.Vessels.PageInfo.EndCursor)
-
-	return nil
+	return Response.Vessels, fetchedPage{
+		totalCount: Response.Vessels.TotalCount.Value,
+		requestID:  requestID,
+		fetchedAt:  time.Now(),
+	}, nil
 }
 
 func wrapAsRecord(in Node, endCursor opencdc.Position) (opencdc.Record, error) {
@@ -166,3 +859,38 @@ func wrapAsRecord(in Node, endCursor opencdc.Position) (opencdc.Record, error) {
 
 	return sdk.Util.Source.NewRecordCreate(endCursor, sdkMetadata, opencdc.RawData(idBytes), opencdc.RawData(b)), nil
 }
+
+func wrapAsTombstone(in Node, endCursor opencdc.Position) (opencdc.Record, error) {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return opencdc.Record{}, fmt.Errorf("error occurred marshalling JSON: %w", err)
+	}
+
+	return sdk.Util.Source.NewRecordDelete(endCursor, make(opencdc.Metadata), opencdc.RawData(in.ID), opencdc.RawData(b)), nil
+}
+
+// nodeDocument returns the JSON representation of the node as a map.
+func nodeDocument(n Node) (map[string]any, error) {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return nil, fmt.Errorf("error occurred marshalling JSON: %w", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("error occurred unmarshalling JSON: %w", err)
+	}
+	return doc, nil
+}
+
+// sleep pauses for the given duration or until the context is cancelled.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -15,9 +15,14 @@
 package ais
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/conduitio/conduit-commons/opencdc"
 	"github.com/machinebox/graphql"
@@ -121,6 +126,195 @@ func TestIterator(t *testing.T) {
 		is.True(record.Payload.After != nil)
 	})
 
+	t.Run("Next_ChangeTracking", func(t *testing.T) {
+		is := is.New(t)
+		it, err := NewIterator(&MockGraphQLClient{}, "test-token", "test-query", 100, nil)
+		is.NoErr(err)
+		is.NoErr(it.applyConfig(SourceConfig{
+			ChangeTracking: ChangeTrackingConfig{
+				Enabled:      true,
+				Patch:        true,
+				IgnoreFields: []string{"timestamp", "updateTimestamp"},
+			},
+		}))
+
+		first := Node{ID: "v1", UpdateTimestamp: "2021-10-01T15:00:00Z"}
+		first.CurrentVoyage.Destination = "ROTTERDAM"
+		second := first
+		second.UpdateTimestamp = "2021-10-01T16:00:00Z"
+		second.CurrentVoyage.Destination = "HAMBURG"
+		second.CurrentVoyage.Draught = 9.5
+		third := second
+		third.UpdateTimestamp = "2021-10-01T17:00:00Z"
+		it.currentBatch = []Node{first, second, third}
+
+		record, err := it.Next(context.Background())
+		is.NoErr(err)
+		_, ok := record.Metadata[MetadataChanged]
+		is.True(!ok) // first observation has no previous state
+
+		record, err = it.Next(context.Background())
+		is.NoErr(err)
+		is.Equal(record.Metadata[MetadataChanged], "currentVoyage.destination,currentVoyage.draught")
+		is.Equal(record.Metadata[MetadataPatch], `[{"op":"replace","path":"/currentVoyage/destination","value":"HAMBURG"},{"op":"replace","path":"/currentVoyage/draught","value":9.5}]`)
+
+		record, err = it.Next(context.Background())
+		is.NoErr(err)
+		_, ok = record.Metadata[MetadataChanged]
+		is.True(!ok) // only ignored fields changed
+		_, ok = record.Metadata[MetadataPatch]
+		is.True(!ok)
+	})
+
+	t.Run("Next_StaleVessels", func(t *testing.T) {
+		is := is.New(t)
+		it, err := NewIterator(&MockGraphQLClient{}, "test-token", "test-query", 100, nil)
+		is.NoErr(err)
+		is.NoErr(it.applyConfig(SourceConfig{StaleAfter: time.Hour}))
+
+		it.store.Put(Node{ID: "gone"}, time.Now().Add(-2*time.Hour))
+		it.store.Put(Node{ID: "recent"}, time.Now().Add(-time.Minute))
+		it.currentBatch = []Node{{ID: "v1", UpdateTimestamp: "2021-10-01T15:00:00Z"}}
+
+		record, err := it.Next(context.Background())
+		is.NoErr(err)
+		is.Equal(record.Operation, opencdc.OperationCreate)
+
+		is.True(it.HasNext(context.Background()))
+		record, err = it.Next(context.Background())
+		is.NoErr(err)
+		is.Equal(record.Operation, opencdc.OperationDelete)
+		is.Equal(record.Key, opencdc.RawData("gone"))
+
+		is.True(!it.HasNext(context.Background()))
+		_, ok := it.store.Get("recent")
+		is.True(ok)
+	})
+
+	t.Run("Next_StaleVesselsEmptySweep", func(t *testing.T) {
+		is := is.New(t)
+		ctx := context.Background()
+		client := &MockGraphQLClient{
+			RunFn: func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+				return nil // no nodes
+			},
+		}
+		it, err := NewIterator(client, "test-token", "test-query", 100, nil)
+		is.NoErr(err)
+		is.NoErr(it.applyConfig(SourceConfig{StaleAfter: time.Hour}))
+
+		// state restored from a previous run
+		it.store.Put(Node{ID: "gone1"}, time.Now().Add(-2*time.Hour))
+		it.store.Put(Node{ID: "gone2"}, time.Now().Add(-3*time.Hour))
+
+		first, err := it.Next(ctx)
+		is.NoErr(err)
+		is.Equal(first.Operation, opencdc.OperationDelete)
+		second, err := it.Next(ctx)
+		is.NoErr(err)
+		is.Equal(second.Operation, opencdc.OperationDelete)
+
+		is.True(first.Position != nil)
+		is.True(second.Position != nil)
+		is.True(!bytes.Equal(first.Position, second.Position))
+		pos, err := ParsePosition(second.Position)
+		is.NoErr(err)
+		is.Equal(pos.Offset, -1)
+		is.Equal(pos.Cursor, "")
+
+		is.NoErr(it.Ack(ctx, first.Position))
+		is.NoErr(it.Ack(ctx, second.Position))
+		is.Equal(it.checkpoints.Inflight(), 0)
+	})
+
+	t.Run("Ack_CommitsInOrder", func(t *testing.T) {
+		is := is.New(t)
+		ctx := context.Background()
+		it, err := NewIterator(&MockGraphQLClient{}, "test-token", "test-query", 100, nil)
+		is.NoErr(err)
+
+		it.currentBatch = []Node{
+			{ID: "v1", UpdateTimestamp: "2021-10-01T15:00:00Z"},
+			{ID: "v2", UpdateTimestamp: "2021-10-01T16:00:00Z"},
+		}
+		first, err := it.Next(ctx)
+		is.NoErr(err)
+		second, err := it.Next(ctx)
+		is.NoErr(err)
+
+		// acking the second record must not commit it while the first is outstanding
+		is.NoErr(it.Ack(ctx, second.Position))
+		is.Equal(it.checkpoints.Committed().Offset, -1)
+		_, ok := it.checkpoints.store.Get("v2")
+		is.True(!ok)
+
+		is.NoErr(it.Ack(ctx, first.Position))
+		committed := it.checkpoints.Committed()
+		is.Equal(committed.Offset, 1)
+		is.Equal(committed.Watermark, time.Date(2021, 10, 1, 16, 0, 0, 0, time.UTC))
+		_, ok = it.checkpoints.store.Get("v2")
+		is.True(ok)
+
+		is.True(it.Ack(ctx, first.Position) != nil) // already acked
+	})
+
+	t.Run("NewIterator_ResumeMidPage", func(t *testing.T) {
+		is := is.New(t)
+		client := &MockGraphQLClient{}
+		position := Position{Cursor: "page-2", Offset: 1}.ToRecordPosition()
+
+		it, err := NewIterator(client, "test-token", "test-query", 100, position)
+		is.NoErr(err)
+
+		client.RunFn = func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+			arg := resp.(*struct{ Vessels Vessels })
+			arg.Vessels.Nodes = []Node{
+				{ID: "v1", UpdateTimestamp: "2021-10-01T15:00:00Z"},
+				{ID: "v2", UpdateTimestamp: "2021-10-01T15:00:00Z"},
+				{ID: "v3", UpdateTimestamp: "2021-10-01T15:00:00Z"},
+			}
+			return nil
+		}
+
+		record, err := it.Next(context.Background())
+		is.NoErr(err)
+		is.Equal(record.Key, opencdc.RawData("v3"))
+
+		pos, err := ParsePosition(record.Position)
+		is.NoErr(err)
+		is.Equal(pos, Position{
+			Cursor:    "page-2",
+			Offset:    2,
+			Watermark: time.Date(2021, 10, 1, 15, 0, 0, 0, time.UTC),
+		})
+	})
+
+	t.Run("loadBatch_StartTime", func(t *testing.T) {
+		is := is.New(t)
+
+		var startTimes []interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Variables map[string]interface{} `json:"variables"`
+			}
+			is.NoErr(json.NewDecoder(r.Body).Decode(&req))
+			startTimes = append(startTimes, req.Variables["startTime"])
+			_, _ = w.Write([]byte(`{"data":{"vessels":{"nodes":[{"id":"v1","updateTimestamp":"2023-11-13T08:00:00Z"}]}}}`))
+		}))
+		defer server.Close()
+
+		it, err := NewIterator(graphql.NewClient(server.URL), "test-token", "test-query", 100, nil)
+		is.NoErr(err)
+		is.NoErr(it.applyConfig(SourceConfig{StartFrom: "2023-11-12T21:00:00Z"}))
+
+		_, err = it.Next(context.Background())
+		is.NoErr(err)
+		// the next result set starts at the watermark of the previous one
+		_, err = it.Next(context.Background())
+		is.NoErr(err)
+		is.Equal(startTimes, []interface{}{"2023-11-12T21:00:00Z", "2023-11-13T08:00:00Z"})
+	})
+
 	t.Run("loadBatch_HappyPath", func(t *testing.T) {
 		is := is.New(t)
 		client := &MockGraphQLClient{}
@@ -157,7 +351,7 @@ func TestIterator(t *testing.T) {
 		is.Equal(mockResponse.Vessels.Nodes, it.currentBatch)
 		is.Equal(mockResponse.Vessels.PageInfo.HasNextPage, it.hasNext)
 		is.Equal(mockResponse.Vessels.PageInfo.EndCursor, it.cursor)
-		is.Equal([]byte(mockResponse.Vessels.PageInfo.EndCursor), it.position)
+		is.Equal(string(position), it.pageCursor) // resumed after the legacy cursor position
 	})
 
 	t.Run("loadBatch_Error", func(t *testing.T) {
@@ -224,7 +418,7 @@ func TestIterator(t *testing.T) {
 		is.Equal(mockResponse.Vessels.Nodes, it.currentBatch)
 		is.Equal(mockResponse.Vessels.PageInfo.HasNextPage, it.hasNext)
 		is.Equal(mockResponse.Vessels.PageInfo.EndCursor, it.cursor)
-		is.Equal([]byte(mockResponse.Vessels.PageInfo.EndCursor), it.position)
-		is.Equal(retries, maxRetries) // Check if the retries have been exhausted
+		is.Equal(string(position), it.pageCursor) // resumed after the legacy cursor position
+		is.Equal(retries, maxRetries)             // Check if the retries have been exhausted
 	})
 }
@@ -0,0 +1,139 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+const (
+	KeyStrategyID         = "id"
+	KeyStrategyMMSI       = "mmsi"
+	KeyStrategyIMO        = "imo"
+	KeyStrategyComposite  = "composite"
+	KeyStrategyStructured = "structured"
+)
+
+var keyStrategies = []string{KeyStrategyID, KeyStrategyMMSI, KeyStrategyIMO, KeyStrategyComposite, KeyStrategyStructured}
+
+type KeyConfig struct {
+	// Strategy determines the record key: "id" uses the Spire vessel ID,
+	// "mmsi" and "imo" the vessel numbers, "composite" joins the values of
+	// Fields with ":" and "structured" uses structured data containing
+	// Fields.
+	Strategy string `json:"strategy" default:"id" validate:"inclusion=id|mmsi|imo|composite|structured"`
+	// Fields are the JSON paths of the fields in composite and structured
+	// keys.
+	Fields []string `json:"fields" default:"staticData.mmsi,staticData.imo"`
+	// Fallback are the strategies tried in order if a field of the key is
+	// missing. The vessel ID is used if none of them yields a key.
+	Fallback []string `json:"fallback" default:"id"`
+}
+
+// keyBuilder creates record keys for vessels.
+type keyBuilder struct {
+	strategies []string
+	fields     []string
+}
+
+func newKeyBuilder(cfg KeyConfig) (*keyBuilder, error) {
+	strategies := slices.Concat([]string{cfg.Strategy}, cfg.Fallback)
+	for _, s := range strategies {
+		if !slices.Contains(keyStrategies, s) {
+			return nil, fmt.Errorf("unknown key strategy %q, expected one of %v", s, keyStrategies)
+		}
+	}
+
+	leaves := modelFields("", reflect.TypeOf(Node{}))
+	names := make(map[string]string)
+	for _, f := range cfg.Fields {
+		if !slices.Contains(leaves, f) {
+			return nil, fmt.Errorf("unknown key field %q", f)
+		}
+		name := f[strings.LastIndex(f, ".")+1:]
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("key fields %q and %q have the same name", other, f)
+		}
+		names[name] = f
+	}
+	needsFields := slices.Contains(strategies, KeyStrategyComposite) || slices.Contains(strategies, KeyStrategyStructured)
+	if needsFields && len(cfg.Fields) == 0 {
+		return nil, fmt.Errorf("key strategies %q and %q require key fields", KeyStrategyComposite, KeyStrategyStructured)
+	}
+
+	return &keyBuilder{strategies: strategies, fields: cfg.Fields}, nil
+}
+
+// Key returns the key of the vessel using the first strategy for which all
+// fields are present.
+func (k *keyBuilder) Key(n Node) opencdc.Data {
+	for _, s := range k.strategies {
+		if key, ok := k.build(s, n); ok {
+			return key
+		}
+	}
+	return opencdc.RawData(n.ID)
+}
+
+func (k *keyBuilder) build(strategy string, n Node) (opencdc.Data, bool) {
+	switch strategy {
+	case KeyStrategyID:
+		return opencdc.RawData(n.ID), n.ID != ""
+	case KeyStrategyMMSI:
+		return opencdc.RawData(strconv.Itoa(n.StaticData.MMSI)), n.StaticData.MMSI != 0
+	case KeyStrategyIMO:
+		return opencdc.RawData(strconv.Itoa(n.StaticData.IMO)), n.StaticData.IMO != 0
+	}
+
+	values := structValues(reflect.ValueOf(n))
+	key := make(opencdc.StructuredData, len(k.fields))
+	parts := make([]string, len(k.fields))
+	for i, f := range k.fields {
+		v, ok := fieldValue(values, f)
+		if !ok {
+			return nil, false
+		}
+		key[f[strings.LastIndex(f, ".")+1:]] = v
+		parts[i] = fmt.Sprint(v)
+	}
+	if strategy == KeyStrategyStructured {
+		return key, true
+	}
+	return opencdc.RawData(strings.Join(parts, ":")), true
+}
+
+// fieldValue returns the value at the dot separated path and whether it is
+// set to a non-zero value.
+func fieldValue(values map[string]any, path string) (any, bool) {
+	head, rest, nested := strings.Cut(path, ".")
+	v, ok := values[head]
+	if !ok {
+		return nil, false
+	}
+	if nested {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		return fieldValue(m, rest)
+	}
+	return v, !reflect.ValueOf(v).IsZero()
+}
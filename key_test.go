@@ -0,0 +1,80 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+)
+
+func TestKeyBuilder(t *testing.T) {
+	full := Node{ID: "v1", StaticData: StaticData{MMSI: 244660000, IMO: 9321483}}
+	noIMO := Node{ID: "v2", StaticData: StaticData{MMSI: 244660000}}
+	bare := Node{ID: "v3"}
+
+	testCases := []struct {
+		name string
+		cfg  KeyConfig
+		node Node
+		want opencdc.Data
+	}{
+		{"ID", KeyConfig{Strategy: KeyStrategyID}, full, opencdc.RawData("v1")},
+		{"MMSI", KeyConfig{Strategy: KeyStrategyMMSI}, full, opencdc.RawData("244660000")},
+		{"IMO", KeyConfig{Strategy: KeyStrategyIMO, Fallback: []string{KeyStrategyMMSI}}, noIMO, opencdc.RawData("244660000")},
+		{"IMOWithoutFallback", KeyConfig{Strategy: KeyStrategyIMO}, bare, opencdc.RawData("v3")},
+		{
+			"Composite",
+			KeyConfig{Strategy: KeyStrategyComposite, Fields: []string{"staticData.mmsi", "staticData.imo"}},
+			full,
+			opencdc.RawData("244660000:9321483"),
+		},
+		{
+			"Structured",
+			KeyConfig{Strategy: KeyStrategyStructured, Fields: []string{"staticData.mmsi", "staticData.imo"}, Fallback: []string{KeyStrategyMMSI}},
+			full,
+			opencdc.StructuredData{"mmsi": 244660000, "imo": 9321483},
+		},
+		{
+			"StructuredFallback",
+			KeyConfig{Strategy: KeyStrategyStructured, Fields: []string{"staticData.mmsi", "staticData.imo"}, Fallback: []string{KeyStrategyMMSI}},
+			noIMO,
+			opencdc.RawData("244660000"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			k, err := newKeyBuilder(tc.cfg)
+			is.NoErr(err)
+			is.Equal(k.Key(tc.node), tc.want)
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		is := is.New(t)
+		for _, cfg := range []KeyConfig{
+			{Strategy: "callsign"},
+			{Strategy: KeyStrategyID, Fallback: []string{"name"}},
+			{Strategy: KeyStrategyComposite},
+			{Strategy: KeyStrategyStructured, Fields: []string{"staticData.unknown"}},
+			{Strategy: KeyStrategyStructured, Fields: []string{"updateTimestamp", "staticData.updateTimestamp"}},
+		} {
+			_, err := newKeyBuilder(cfg)
+			is.True(err != nil)
+		}
+	})
+}
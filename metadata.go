@@ -0,0 +1,98 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+// Metadata keys describing the vessel and the request it was read with.
+const (
+	MetadataMMSI       = "spire.mmsi"
+	MetadataIMO        = "spire.imo"
+	MetadataShipType   = "spire.shipType"
+	MetadataFlag       = "spire.flag"
+	MetadataQuery      = "spire.query"
+	MetadataPageCursor = "spire.pageCursor"
+	MetadataPageNumber = "spire.pageNumber"
+	MetadataTotalCount = "spire.totalCount"
+	MetadataRequestID  = "spire.requestId"
+	MetadataFetchedAt  = "spire.fetchedAt"
+)
+
+// requestIDHeader is the header carrying the ID generated for every request.
+const requestIDHeader = "X-Request-Id"
+
+// watchlistQueryName is the query name of records read by the watchlist.
+const watchlistQueryName = "watchlist"
+
+// fetchedPage describes the response a node was read from.
+type fetchedPage struct {
+	query string
+	// cursor is the cursor the page was requested with.
+	cursor string
+	// number is the 1-based index of the page within its result set.
+	number     int
+	totalCount int
+	requestID  string
+	fetchedAt  time.Time
+}
+
+// queryName returns the name recorded as the query of records: the operation
+// name, the name of the query file or "vessels" for the default query.
+func queryName(cfg SourceConfig) string {
+	switch {
+	case cfg.OperationName != "":
+		return cfg.OperationName
+	case cfg.QueryFile != "":
+		base := filepath.Base(cfg.QueryFile)
+		return strings.TrimSuffix(base, filepath.Ext(base))
+	default:
+		return "vessels"
+	}
+}
+
+// setVesselMetadata adds the identity and type of the vessel to the metadata.
+// Fields that are not set are omitted.
+func setVesselMetadata(n Node, metadata opencdc.Metadata) {
+	if n.StaticData.MMSI != 0 {
+		metadata[MetadataMMSI] = strconv.Itoa(n.StaticData.MMSI)
+	}
+	if n.StaticData.IMO != 0 {
+		metadata[MetadataIMO] = strconv.Itoa(n.StaticData.IMO)
+	}
+	if n.StaticData.ShipType != "" {
+		metadata[MetadataShipType] = n.StaticData.ShipType
+	}
+	if n.StaticData.Flag != "" {
+		metadata[MetadataFlag] = n.StaticData.Flag
+	}
+}
+
+// setPageMetadata adds the details of the request a node was read with to the
+// metadata.
+func setPageMetadata(page fetchedPage, metadata opencdc.Metadata) {
+	metadata[MetadataQuery] = page.query
+	metadata[MetadataPageCursor] = page.cursor
+	metadata[MetadataPageNumber] = strconv.Itoa(page.number)
+	metadata[MetadataTotalCount] = strconv.Itoa(page.totalCount)
+	metadata[MetadataRequestID] = page.requestID
+	metadata[MetadataFetchedAt] = page.fetchedAt.Format(time.RFC3339Nano)
+}
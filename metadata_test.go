@@ -0,0 +1,86 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+)
+
+func TestIterator_Metadata(t *testing.T) {
+	is := is.New(t)
+
+	var requestIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		requestIDs = append(requestIDs, r.Header.Get(requestIDHeader))
+		if len(requestIDs) == 1 {
+			_, _ = w.Write([]byte(`{"data":{"vessels":{"pageInfo":{"hasNextPage":true,"endCursor":"c1"},"totalCount":{"value":2},` +
+				`"nodes":[{"id":"a","updateTimestamp":"2024-01-01T00:00:00Z","staticData":{"mmsi":244660000,"imo":9321483,"shipType":"TANKER","flag":"NL"}}]}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"vessels":{"totalCount":{"value":2},` +
+			`"nodes":[{"id":"b","updateTimestamp":"2024-01-01T00:00:00Z"}]}}}`))
+	}))
+	defer server.Close()
+
+	query := strings.Replace(vesselQuery(), "query (", "query Tankers (", 1)
+	it, err := NewIterator(graphql.NewClient(server.URL), "test-token", query, 1, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{OperationName: "Tankers"}))
+
+	ctx := context.Background()
+	first, err := it.Next(ctx)
+	is.NoErr(err)
+	second, err := it.Next(ctx)
+	is.NoErr(err)
+
+	fetchedAt, err := time.Parse(time.RFC3339Nano, first.Metadata[MetadataFetchedAt])
+	is.NoErr(err)
+	is.True(time.Since(fetchedAt) < time.Minute)
+	spire := make(map[string]string)
+	for k, v := range first.Metadata {
+		if strings.HasPrefix(k, "spire.") && k != MetadataFetchedAt {
+			spire[k] = v
+		}
+	}
+	is.Equal(spire, map[string]string{
+		MetadataMMSI:       "244660000",
+		MetadataIMO:        "9321483",
+		MetadataShipType:   "TANKER",
+		MetadataFlag:       "NL",
+		MetadataQuery:      "Tankers",
+		MetadataPageCursor: "",
+		MetadataPageNumber: "1",
+		MetadataTotalCount: "2",
+		MetadataRequestID:  requestIDs[0],
+	})
+
+	// vessel fields that are not set are omitted
+	_, ok := second.Metadata[MetadataMMSI]
+	is.True(!ok)
+	is.Equal(second.Metadata[MetadataPageCursor], "c1")
+	is.Equal(second.Metadata[MetadataPageNumber], "2")
+	is.Equal(second.Metadata[MetadataRequestID], requestIDs[1])
+	is.True(requestIDs[0] != requestIDs[1])
+}
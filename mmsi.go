@@ -0,0 +1,118 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MMSI types as defined in ITU-R M.585.
+const (
+	MMSITypeShip           = "SHIP"
+	MMSITypeGroup          = "GROUP"
+	MMSITypeCoastStation   = "COAST_STATION"
+	MMSITypeSARAircraft    = "SAR_AIRCRAFT"
+	MMSITypeAtoN           = "ATON"
+	MMSITypeAuxiliaryCraft = "AUXILIARY_CRAFT"
+	MMSITypeHandheld       = "HANDHELD"
+	MMSITypeSART           = "SART"
+	MMSITypeMOB            = "MOB"
+	MMSITypeEPIRB          = "EPIRB"
+	MMSITypeUnknown        = "UNKNOWN"
+)
+
+// midTable maps the Maritime Identification Digits to the ISO 3166-1 alpha-2
+// code of the country they are assigned to.
+//
+//go:embed data/mid.csv
+var midTable []byte
+
+var loadMIDs = sync.OnceValues(func() (map[string]string, error) {
+	r := csv.NewReader(bytes.NewReader(midTable))
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MID table: %w", err)
+	}
+	mids := make(map[string]string, len(records))
+	for _, rec := range records[1:] {
+		mids[rec[0]] = rec[1]
+	}
+	return mids, nil
+})
+
+// mmsiInfo is the decoded structure of an MMSI.
+type mmsiInfo struct {
+	Type string `json:"type"`
+	// Valid is false if the MMSI does not match any of the formats or its
+	// MID is not assigned.
+	Valid bool   `json:"valid"`
+	MID   string `json:"mid,omitempty"`
+	// Country is the ISO 3166-1 alpha-2 code of the country the MID is
+	// assigned to.
+	Country string `json:"country,omitempty"`
+	// FlagMismatch is set if the country of the MID differs from the
+	// reported flag of the vessel.
+	FlagMismatch bool `json:"flagMismatch"`
+}
+
+// decodeMMSI classifies the MMSI by its leading digits and resolves the
+// country of its MID.
+func decodeMMSI(mmsi int, flag string) (mmsiInfo, error) {
+	if mmsi <= 0 || mmsi > 999_999_999 {
+		return mmsiInfo{Type: MMSITypeUnknown}, nil
+	}
+	// leading zeros are part of the MMSI
+	s := fmt.Sprintf("%09d", mmsi)
+
+	var info mmsiInfo
+	switch {
+	case strings.HasPrefix(s, "111"):
+		info.Type, info.MID = MMSITypeSARAircraft, s[3:6]
+	case strings.HasPrefix(s, "00"):
+		info.Type, info.MID = MMSITypeCoastStation, s[2:5]
+	case strings.HasPrefix(s, "0"):
+		info.Type, info.MID = MMSITypeGroup, s[1:4]
+	case strings.HasPrefix(s, "970"):
+		return mmsiInfo{Type: MMSITypeSART, Valid: true}, nil
+	case strings.HasPrefix(s, "972"):
+		return mmsiInfo{Type: MMSITypeMOB, Valid: true}, nil
+	case strings.HasPrefix(s, "974"):
+		return mmsiInfo{Type: MMSITypeEPIRB, Valid: true}, nil
+	case strings.HasPrefix(s, "99"):
+		info.Type, info.MID = MMSITypeAtoN, s[2:5]
+	case strings.HasPrefix(s, "98"):
+		info.Type, info.MID = MMSITypeAuxiliaryCraft, s[2:5]
+	case strings.HasPrefix(s, "8"):
+		info.Type, info.MID = MMSITypeHandheld, s[1:4]
+	case s[0] >= '2' && s[0] <= '7':
+		info.Type, info.MID = MMSITypeShip, s[:3]
+	default:
+		return mmsiInfo{Type: MMSITypeUnknown}, nil
+	}
+
+	mids, err := loadMIDs()
+	if err != nil {
+		return mmsiInfo{}, err
+	}
+	info.Country, info.Valid = mids[info.MID]
+	info.FlagMismatch = info.Valid && flag != "" && !strings.EqualFold(flag, info.Country)
+	return info, nil
+}
@@ -0,0 +1,92 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+)
+
+func TestDecodeMMSI(t *testing.T) {
+	testCases := []struct {
+		name string
+		mmsi int
+		flag string
+		want mmsiInfo
+	}{
+		{"Ship", 244660000, "NL", mmsiInfo{Type: MMSITypeShip, Valid: true, MID: "244", Country: "NL"}},
+		{"FlagMismatch", 636012345, "PA", mmsiInfo{Type: MMSITypeShip, Valid: true, MID: "636", Country: "LR", FlagMismatch: true}},
+		{"UnassignedMID", 222123456, "", mmsiInfo{Type: MMSITypeShip, MID: "222"}},
+		{"Group", 24412345, "", mmsiInfo{Type: MMSITypeGroup, Valid: true, MID: "244", Country: "NL"}},
+		{"CoastStation", 2320001, "", mmsiInfo{Type: MMSITypeCoastStation, Valid: true, MID: "232", Country: "GB"}},
+		{"SARAircraft", 111232506, "", mmsiInfo{Type: MMSITypeSARAircraft, Valid: true, MID: "232", Country: "GB"}},
+		{"AtoN", 992446000, "", mmsiInfo{Type: MMSITypeAtoN, Valid: true, MID: "244", Country: "NL"}},
+		{"AuxiliaryCraft", 982191234, "", mmsiInfo{Type: MMSITypeAuxiliaryCraft, Valid: true, MID: "219", Country: "DK"}},
+		{"Handheld", 824412345, "", mmsiInfo{Type: MMSITypeHandheld, Valid: true, MID: "244", Country: "NL"}},
+		{"SART", 970012345, "", mmsiInfo{Type: MMSITypeSART, Valid: true}},
+		{"MOB", 972012345, "", mmsiInfo{Type: MMSITypeMOB, Valid: true}},
+		{"EPIRB", 974012345, "", mmsiInfo{Type: MMSITypeEPIRB, Valid: true}},
+		{"Sequence", 123456789, "", mmsiInfo{Type: MMSITypeUnknown}},
+		{"TooLong", 1234567890, "", mmsiInfo{Type: MMSITypeUnknown}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			got, err := decodeMMSI(tc.mmsi, tc.flag)
+			is.NoErr(err)
+			is.Equal(got, tc.want)
+		})
+	}
+}
+
+func TestIterator_DeriveMMSI(t *testing.T) {
+	is := is.New(t)
+
+	client := &MockGraphQLClient{
+		RunFn: func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+			arg := resp.(*struct{ Vessels Vessels })
+			arg.Vessels.Nodes = []Node{testVessel, {ID: "unknown", UpdateTimestamp: "2024-01-01T12:00:00Z"}}
+			return nil
+		},
+	}
+	it, err := NewIterator(client, "test-token", vesselQuery(), 2, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{Derive: DeriveConfig{MMSI: true}}))
+
+	var got struct {
+		Derived map[string]any `json:"derived"`
+	}
+	record, err := it.Next(context.Background())
+	is.NoErr(err)
+	is.NoErr(json.Unmarshal(record.Payload.After.Bytes(), &got))
+	is.Equal(got.Derived["mmsi"], map[string]any{
+		"type":         MMSITypeShip,
+		"valid":        true,
+		"mid":          "244",
+		"country":      "NL",
+		"flagMismatch": false,
+	})
+
+	// vessels without MMSI have no decoded MMSI
+	record, err = it.Next(context.Background())
+	is.NoErr(err)
+	got.Derived = nil
+	is.NoErr(json.Unmarshal(record.Payload.After.Bytes(), &got))
+	is.Equal(len(got.Derived), 0)
+}
@@ -0,0 +1,125 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	NormalizeModeNull = "null"
+	NormalizeModeDrop = "drop"
+)
+
+// MetadataNormalized is the metadata key listing the fields of a record that
+// contained a "not available" sentinel value.
+const MetadataNormalized = "ais.normalized"
+
+// sentinel is the value AIS uses to signal that a field is not available.
+type sentinel struct {
+	path  string
+	value float64
+	field func(n Node) float64
+}
+
+var sentinels = []sentinel{
+	{"lastPositionUpdate.heading", 511, func(n Node) float64 { return n.LastPositionUpdate.Heading }},
+	{"lastPositionUpdate.speed", 102.3, func(n Node) float64 { return n.LastPositionUpdate.Speed }},
+	{"lastPositionUpdate.rot", -128, func(n Node) float64 { return n.LastPositionUpdate.Rot }},
+	{"lastPositionUpdate.latitude", 91, func(n Node) float64 { return n.LastPositionUpdate.Latitude }},
+	{"lastPositionUpdate.longitude", 181, func(n Node) float64 { return n.LastPositionUpdate.Longitude }},
+	{"currentVoyage.draught", 0, func(n Node) float64 { return n.CurrentVoyage.Draught }},
+}
+
+type NormalizeConfig struct {
+	// Fields are the fields whose "not available" sentinel value is
+	// normalized, e.g. "lastPositionUpdate.heading". Supported are heading
+	// (511), speed (102.3), rot (-128), latitude (91) and longitude (181) of
+	// lastPositionUpdate and currentVoyage.draught (0).
+	Fields []string `json:"fields"`
+	// Mode determines how sentinel values appear in JSON payloads: "null"
+	// sets the field to null, "drop" removes the field.
+	Mode string `json:"mode" default:"null" validate:"inclusion=null|drop"`
+}
+
+// normalizer replaces AIS "not available" sentinel values.
+type normalizer struct {
+	fields []string
+	drop   bool
+}
+
+func newNormalizer(cfg NormalizeConfig) (*normalizer, error) {
+	for _, f := range cfg.Fields {
+		if !slices.ContainsFunc(sentinels, func(s sentinel) bool { return s.path == f }) {
+			return nil, fmt.Errorf("field %q has no sentinel value", f)
+		}
+	}
+	return &normalizer{
+		fields: cfg.Fields,
+		drop:   cfg.Mode == NormalizeModeDrop,
+	}, nil
+}
+
+// Normalize returns the paths of the configured fields that hold their
+// sentinel value. The node itself is left as is, the fields are only cleared
+// in payload documents and are null in filters.
+func (z *normalizer) Normalize(n Node) []string {
+	var normalized []string
+	for _, s := range sentinels {
+		if slices.Contains(z.fields, s.path) && s.field(n) == s.value {
+			normalized = append(normalized, s.path)
+		}
+	}
+	return normalized
+}
+
+// nullable returns the paths of the configured fields, which can be null in
+// filters.
+func (z *normalizer) nullable() []string {
+	if z == nil {
+		return nil
+	}
+	return z.fields
+}
+
+// Clear sets the fields at the given paths of the node document to null, or
+// removes them in drop mode. Fields missing in the document are ignored.
+func (z *normalizer) Clear(doc map[string]any, paths []string) {
+	for _, path := range paths {
+		parent, key := doc, path
+		for {
+			head, rest, nested := strings.Cut(key, ".")
+			if !nested {
+				break
+			}
+			m, ok := parent[head].(map[string]any)
+			if !ok {
+				parent = nil
+				break
+			}
+			parent, key = m, rest
+		}
+		if _, ok := parent[key]; !ok {
+			continue
+		}
+		if z.drop {
+			delete(parent, key)
+		} else {
+			parent[key] = nil
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+)
+
+func TestNormalizer(t *testing.T) {
+	unavailable := Node{
+		ID: "vessel-1",
+		LastPositionUpdate: LastPositionUpdate{
+			Heading:   511,
+			Speed:     102.3,
+			Rot:       -128,
+			Latitude:  91,
+			Longitude: 181,
+			Course:    90,
+		},
+	}
+
+	t.Run("Normalize", func(t *testing.T) {
+		is := is.New(t)
+		z, err := newNormalizer(NormalizeConfig{Fields: []string{
+			"lastPositionUpdate.heading",
+			"lastPositionUpdate.speed",
+			"currentVoyage.draught",
+		}})
+		is.NoErr(err)
+
+		normalized := z.Normalize(unavailable)
+		is.Equal(normalized, []string{"lastPositionUpdate.heading", "lastPositionUpdate.speed", "currentVoyage.draught"})
+		// the node is unchanged
+		is.Equal(unavailable.LastPositionUpdate.Heading, 511.0)
+
+		normalized = z.Normalize(testVessel)
+		is.Equal(len(normalized), 0)
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		is := is.New(t)
+		paths := []string{"lastPositionUpdate.heading", "currentVoyage.draught"}
+
+		doc := map[string]any{
+			"lastPositionUpdate": map[string]any{"heading": 0.0, "course": 90.0},
+		}
+		z := &normalizer{}
+		z.Clear(doc, paths)
+		is.Equal(doc, map[string]any{
+			"lastPositionUpdate": map[string]any{"heading": nil, "course": 90.0},
+		})
+
+		z.drop = true
+		z.Clear(doc, paths)
+		is.Equal(doc, map[string]any{
+			"lastPositionUpdate": map[string]any{"course": 90.0},
+		})
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		is := is.New(t)
+		_, err := newNormalizer(NormalizeConfig{Fields: []string{"lastPositionUpdate.course"}})
+		is.True(err != nil)
+	})
+}
+
+func TestIterator_Normalize(t *testing.T) {
+	is := is.New(t)
+
+	vessel := testVessel
+	vessel.LastPositionUpdate.Heading = 511
+	client := &MockGraphQLClient{
+		RunFn: func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+			arg := resp.(*struct{ Vessels Vessels })
+			arg.Vessels.Nodes = []Node{vessel}
+			return nil
+		},
+	}
+	it, err := NewIterator(client, "test-token", vesselQuery(), 1, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{
+		Normalize: NormalizeConfig{
+			Fields: []string{"lastPositionUpdate.heading", "lastPositionUpdate.speed"},
+			Mode:   NormalizeModeNull,
+		},
+	}))
+
+	record, err := it.Next(context.Background())
+	is.NoErr(err)
+	is.Equal(record.Metadata[MetadataNormalized], "lastPositionUpdate.heading")
+
+	var got struct {
+		LastPositionUpdate map[string]any `json:"lastPositionUpdate"`
+	}
+	is.NoErr(json.Unmarshal(record.Payload.After.Bytes(), &got))
+	heading, ok := got.LastPositionUpdate["heading"]
+	is.True(ok)
+	is.Equal(heading, nil)
+	is.Equal(got.LastPositionUpdate["speed"], 12.5)
+}
+
+func TestIterator_NormalizeLatitude(t *testing.T) {
+	is := is.New(t)
+
+	unavailable := testVessel
+	unavailable.ID = "vessel-2"
+	unavailable.LastPositionUpdate.Latitude = 91
+	client := &MockGraphQLClient{
+		RunFn: func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+			arg := resp.(*struct{ Vessels Vessels })
+			arg.Vessels.Nodes = []Node{unavailable, testVessel}
+			return nil
+		},
+	}
+	it, err := NewIterator(client, "test-token", vesselQuery(), 2, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{
+		Payload:   PayloadConfig{Format: PayloadFormatGeoJSON},
+		Normalize: NormalizeConfig{Fields: []string{"lastPositionUpdate.latitude"}, Mode: NormalizeModeNull},
+		// the latitude is null, not 0
+		Exclude: `latitude != null && latitude < 1.0`,
+	}))
+
+	record, err := it.Next(context.Background())
+	is.NoErr(err)
+	is.Equal(record.Key, opencdc.RawData("vessel-2"))
+	is.Equal(record.Metadata[MetadataNormalized], "lastPositionUpdate.latitude")
+
+	var f map[string]any
+	is.NoErr(json.Unmarshal(record.Payload.After.Bytes(), &f))
+	geometry, ok := f["geometry"]
+	is.True(ok)
+	is.Equal(geometry, nil) // no point on the equator
+}
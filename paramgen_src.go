@@ -8,10 +8,63 @@ import (
 )
 
 const (
-	SourceConfigApiUrl    = "apiUrl"
-	SourceConfigBatchSize = "batchSize"
-	SourceConfigQuery     = "query"
-	SourceConfigToken     = "token"
+	SourceConfigApiUrl                        = "apiUrl"
+	SourceConfigBackfillEnabled               = "backfill.enabled"
+	SourceConfigBackfillEnd                   = "backfill.end"
+	SourceConfigBackfillMaxCount              = "backfill.maxCount"
+	SourceConfigBackfillStart                 = "backfill.start"
+	SourceConfigBackfillWindow                = "backfill.window"
+	SourceConfigBatchSize                     = "batchSize"
+	SourceConfigChangeTrackingEnabled         = "changeTracking.enabled"
+	SourceConfigChangeTrackingIgnoreFields    = "changeTracking.ignoreFields"
+	SourceConfigChangeTrackingPatch           = "changeTracking.patch"
+	SourceConfigCollectionDefault             = "collection.default"
+	SourceConfigCollectionMapping             = "collection.mapping.*"
+	SourceConfigCollectionTemplate            = "collection.template"
+	SourceConfigDeriveGeometry                = "derive.geometry"
+	SourceConfigDeriveHullPolygon             = "derive.hullPolygon"
+	SourceConfigDeriveMmsi                    = "derive.mmsi"
+	SourceConfigDeriveRateOfTurn              = "derive.rateOfTurn"
+	SourceConfigErrorsCollection              = "errors.collection"
+	SourceConfigErrorsMode                    = "errors.mode"
+	SourceConfigExclude                       = "exclude"
+	SourceConfigExcludeFields                 = "excludeFields"
+	SourceConfigFields                        = "fields"
+	SourceConfigFilter                        = "filter"
+	SourceConfigFragmentFiles                 = "fragmentFiles"
+	SourceConfigKeyFallback                   = "key.fallback"
+	SourceConfigKeyFields                     = "key.fields"
+	SourceConfigKeyStrategy                   = "key.strategy"
+	SourceConfigNormalizeFields               = "normalize.fields"
+	SourceConfigNormalizeMode                 = "normalize.mode"
+	SourceConfigOperationName                 = "operationName"
+	SourceConfigPayloadCloudEventsRemovedType = "payload.cloudEvents.removedType"
+	SourceConfigPayloadCloudEventsType        = "payload.cloudEvents.type"
+	SourceConfigPayloadEncoding               = "payload.encoding"
+	SourceConfigPayloadEnvelope               = "payload.envelope"
+	SourceConfigPayloadFormat                 = "payload.format"
+	SourceConfigPayloadTrack                  = "payload.track"
+	SourceConfigPayloadTrackVessels           = "payload.trackVessels"
+	SourceConfigPollCron                      = "poll.cron"
+	SourceConfigPollInterval                  = "poll.interval"
+	SourceConfigPollMaxInterval               = "poll.maxInterval"
+	SourceConfigPollMinInterval               = "poll.minInterval"
+	SourceConfigPollMode                      = "poll.mode"
+	SourceConfigPollTargetCount               = "poll.targetCount"
+	SourceConfigQuery                         = "query"
+	SourceConfigQueryFile                     = "queryFile"
+	SourceConfigQueryVariables                = "queryVariables.*"
+	SourceConfigResetPosition                 = "resetPosition"
+	SourceConfigRunOnceEnabled                = "runOnce.enabled"
+	SourceConfigRunOnceEndTime                = "runOnce.endTime"
+	SourceConfigStaleAfter                    = "staleAfter"
+	SourceConfigStartFrom                     = "startFrom"
+	SourceConfigStatePath                     = "statePath"
+	SourceConfigTieredPollingEnabled          = "tieredPolling.enabled"
+	SourceConfigTieredPollingStaticInterval   = "tieredPolling.staticInterval"
+	SourceConfigToken                         = "token"
+	SourceConfigWatchlistInterval             = "watchlist.interval"
+	SourceConfigWatchlistPath                 = "watchlist.path"
 )
 
 func (SourceConfig) Parameters() map[string]config.Parameter {
@@ -22,18 +75,338 @@ func (SourceConfig) Parameters() map[string]config.Parameter {
 			Type:        config.ParameterTypeString,
 			Validations: []config.Validation{},
 		},
+		SourceConfigBackfillEnabled: {
+			Default:     "false",
+			Description: "Enabled walks the time range from Start to End in windows before\ncontinuing with regular polling from End.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		SourceConfigBackfillEnd: {
+			Default:     "",
+			Description: "End is the RFC3339 timestamp at which the backfill ends (exclusive). It\ndefaults to the time the connector is opened.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigBackfillMaxCount: {
+			Default:     "10000",
+			Description: "MaxCount is the total count above which a window is split in half.",
+			Type:        config.ParameterTypeInt,
+			Validations: []config.Validation{},
+		},
+		SourceConfigBackfillStart: {
+			Default:     "",
+			Description: "Start is the RFC3339 timestamp at which the backfill starts.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigBackfillWindow: {
+			Default:     "24h",
+			Description: "Window is the time range queried at once.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
 		SourceConfigBatchSize: {
 			Default:     "100",
 			Description: "batchSize is the quantity of vessels to retrieve per Spire GraphQL API call.",
 			Type:        config.ParameterTypeInt,
 			Validations: []config.Validation{},
 		},
+		SourceConfigChangeTrackingEnabled: {
+			Default:     "false",
+			Description: "Enabled attaches the paths of the fields that changed since the previous\nobservation of a vessel to the record metadata.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		SourceConfigChangeTrackingIgnoreFields: {
+			Default:     "timestamp,updateTimestamp",
+			Description: "IgnoreFields are field names that are never reported as changed.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigChangeTrackingPatch: {
+			Default:     "false",
+			Description: "Patch additionally attaches a compact JSON patch describing the changes.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		SourceConfigCollectionDefault: {
+			Default:     "",
+			Description: "Default is the collection of vessels for which the template renders an\nempty string, prints an empty field or a value that is not in the\nmapping.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigCollectionMapping: {
+			Default:     "",
+			Description: "Mapping groups values in the template using the \"group\" function, e.g.\n\"ais_{{ .StaticData.ShipType | group }}\".",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigCollectionTemplate: {
+			Default:     "",
+			Description: "Template is a Go text/template rendered with the vessel node that sets\nthe collection of each record, e.g. \"ais_{{ .StaticData.ShipType |\nlower }}\".",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigDeriveGeometry: {
+			Default:     "false",
+			Description: "Geometry derives the length, beam and antenna offset of the vessel\nfrom the AIS dimensions.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		SourceConfigDeriveHullPolygon: {
+			Default:     "false",
+			Description: "HullPolygon adds the outline of the hull around the reported position\nto the geometry, oriented by the heading or, if not available, the\ncourse.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		SourceConfigDeriveMmsi: {
+			Default:     "false",
+			Description: "MMSI decodes the type and country of the MMSI, validates it and\ncompares the country with the flag of the vessel.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		SourceConfigDeriveRateOfTurn: {
+			Default:     "false",
+			Description: "RateOfTurn decodes the AIS rate of turn indicator into degrees per\nminute and a turn state.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		SourceConfigErrorsCollection: {
+			Default:     "",
+			Description: "Collection is the collection malformed nodes are emitted to in lenient\nmode. If empty, malformed nodes are skipped.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigErrorsMode: {
+			Default:     "strict",
+			Description: "Mode determines how nodes that cannot be converted to records, e.g.\nbecause of an unparseable update timestamp, are handled: \"strict\"\nstops the pipeline with an error, \"lenient\" emits them to Collection\nor skips them.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{
+				config.ValidationInclusion{List: []string{"strict", "lenient"}},
+			},
+		},
+		SourceConfigExclude: {
+			Default:     "",
+			Description: "Exclude is a CEL expression evaluated against each vessel, vessels for\nwhich it is true are dropped, e.g. `speed > 12 && navigationalStatus\n== \"MOORED\"`.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigExcludeFields: {
+			Default:     "",
+			Description: "ExcludeFields removes fields from the selection of the default query.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigFields: {
+			Default:     "",
+			Description: "Fields limits the fields selected by the default query, e.g.\n\"staticData.mmsi,lastPositionUpdate.*\". The vessel ID and update\ntimestamp are always selected.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigFilter: {
+			Default:     "",
+			Description: "Filter is a CEL expression evaluated against each vessel, only vessels\nfor which it is true are emitted, e.g. `speed < 50`.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigFragmentFiles: {
+			Default:     "",
+			Description: "FragmentFiles are paths or glob patterns of .graphql files containing\nfragments shared between query files.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigKeyFallback: {
+			Default:     "id",
+			Description: "Fallback are the strategies tried in order if a field of the key is\nmissing. The vessel ID is used if none of them yields a key.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigKeyFields: {
+			Default:     "staticData.mmsi,staticData.imo",
+			Description: "Fields are the JSON paths of the fields in composite and structured\nkeys.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigKeyStrategy: {
+			Default:     "id",
+			Description: "Strategy determines the record key: \"id\" uses the Spire vessel ID,\n\"mmsi\" and \"imo\" the vessel numbers, \"composite\" joins the values of\nFields with \":\" and \"structured\" uses structured data containing\nFields.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{
+				config.ValidationInclusion{List: []string{"id", "mmsi", "imo", "composite", "structured"}},
+			},
+		},
+		SourceConfigNormalizeFields: {
+			Default:     "",
+			Description: "Fields are the fields whose \"not available\" sentinel value is\nnormalized, e.g. \"lastPositionUpdate.heading\". Supported are heading\n(511), speed (102.3), rot (-128), latitude (91) and longitude (181) of\nlastPositionUpdate and currentVoyage.draught (0).",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigNormalizeMode: {
+			Default:     "null",
+			Description: "Mode determines how sentinel values appear in JSON payloads: \"null\"\nsets the field to null, \"drop\" removes the field.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{
+				config.ValidationInclusion{List: []string{"null", "drop"}},
+			},
+		},
+		SourceConfigOperationName: {
+			Default:     "",
+			Description: "OperationName selects the operation to run if the query document\ncontains several operations.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigPayloadCloudEventsRemovedType: {
+			Default:     "com.spire.ais.vessel.removed",
+			Description: "RemovedType is the type of events for vessels that became stale.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigPayloadCloudEventsType: {
+			Default:     "com.spire.ais.vessel.position",
+			Description: "Type is the type of events for vessels read from the API.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigPayloadEncoding: {
+			Default:     "json",
+			Description: "Encoding is the encoding of \"json\" payloads: \"json\", or \"protobuf\" and\n\"avro\" binary using the schemas in the schema directory.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{
+				config.ValidationInclusion{List: []string{"json", "protobuf", "avro"}},
+			},
+		},
+		SourceConfigPayloadEnvelope: {
+			Default:     "none",
+			Description: "Envelope wraps the payload: \"none\" or \"cloudevents\" for a CloudEvents\n1.0 event in the structured JSON format.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{
+				config.ValidationInclusion{List: []string{"none", "cloudevents"}},
+			},
+		},
+		SourceConfigPayloadFormat: {
+			Default:     "json",
+			Description: "Format is the payload format: \"json\" is the vessel node as returned by\nthe API, \"geojson\" a GeoJSON Feature with the position as geometry.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{
+				config.ValidationInclusion{List: []string{"json", "geojson"}},
+			},
+		},
+		SourceConfigPayloadTrack: {
+			Default:     "0",
+			Description: "Track is the number of recent positions of a vessel included as a\nLineString in GeoJSON payloads. A value below 2 disables the track.",
+			Type:        config.ParameterTypeInt,
+			Validations: []config.Validation{},
+		},
+		SourceConfigPayloadTrackVessels: {
+			Default:     "10000",
+			Description: "TrackVessels is the number of vessels whose track is kept in memory.\nThe track of the least recently updated vessel is dropped first, 0\nkeeps all tracks.",
+			Type:        config.ParameterTypeInt,
+			Validations: []config.Validation{},
+		},
+		SourceConfigPollCron: {
+			Default:     "",
+			Description: "Cron is a cron expression with 5 fields or a descriptor like \"@hourly\".",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigPollInterval: {
+			Default:     "1m",
+			Description: "Interval is the time between the start of two polls. In adaptive mode\nit is the initial interval.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
+		SourceConfigPollMaxInterval: {
+			Default:     "10m",
+			Description: "MaxInterval is the longest interval in adaptive mode.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
+		SourceConfigPollMinInterval: {
+			Default:     "10s",
+			Description: "MinInterval is the shortest interval in adaptive mode.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
+		SourceConfigPollMode: {
+			Default:     "backoff",
+			Description: "Mode is how polls for new updates are scheduled once a result set was\nread completely: \"backoff\" leaves the pacing to the SDK's backoff,\n\"interval\" polls every Interval, \"cron\" polls according to Cron and\n\"adaptive\" adjusts the interval to the number of updated vessels.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{
+				config.ValidationInclusion{List: []string{"backoff", "interval", "cron", "adaptive"}},
+			},
+		},
+		SourceConfigPollTargetCount: {
+			Default:     "1000",
+			Description: "TargetCount is the number of updated vessels per poll the adaptive\nmode aims for.",
+			Type:        config.ParameterTypeInt,
+			Validations: []config.Validation{},
+		},
 		SourceConfigQuery: {
 			Default:     "",
-			Description: "Query is the GraphQL Query to use when pulling data from the Spire API.",
+			Description: "Query is the GraphQL Query to use when pulling data from the Spire API.\nIt is a Go text/template rendered before every request, see QueryData\nfor the available fields.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigQueryFile: {
+			Default:     "",
+			Description: "QueryFile is a .graphql file the query is loaded from instead of Query.\nThe file is reloaded when it changes.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigQueryVariables: {
+			Default:     "",
+			Description: "QueryVariables are user defined values available as .Vars in the query\ntemplate.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigResetPosition: {
+			Default:     "false",
+			Description: "ResetPosition ignores the saved position and starts reading from\nStartFrom.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		SourceConfigRunOnceEnabled: {
+			Default:     "false",
+			Description: "Enabled stops the source once the last page of the result set was read\ninstead of polling for new updates.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		SourceConfigRunOnceEndTime: {
+			Default:     "",
+			Description: "EndTime is an optional RFC3339 timestamp, vessels updated after it are\nnot emitted.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigStaleAfter: {
+			Default:     "0",
+			Description: "StaleAfter is the time after which a vessel that did not appear in any\nresult set is considered gone and a delete record is emitted for it. A\nvalue of 0 disables stale vessel detection.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
+		SourceConfigStartFrom: {
+			Default:     "now",
+			Description: "StartFrom is where reading starts if there is no saved position. It\naccepts \"now\", \"earliest\", a negative duration relative to now (e.g.\n\"-6h\") or an RFC3339 timestamp. It is passed to the query as the\n$startTime variable.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
+		SourceConfigStatePath: {
+			Default:     "",
+			Description: "StatePath is the file in which the last acknowledged state of each\nvessel is persisted. If empty, the state is only kept in memory.",
 			Type:        config.ParameterTypeString,
 			Validations: []config.Validation{},
 		},
+		SourceConfigTieredPollingEnabled: {
+			Default:     "false",
+			Description: "Enabled polls only the position updates with every request and fetches\nthe static data and current voyage of the polled vessels on a slower\nschedule. The cached data is merged into every record.",
+			Type:        config.ParameterTypeBool,
+			Validations: []config.Validation{},
+		},
+		SourceConfigTieredPollingStaticInterval: {
+			Default:     "1h",
+			Description: "StaticInterval is the time after which the static data of a vessel is\nrefreshed.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
 		SourceConfigToken: {
 			Default:     "",
 			Description: "Token is the access token to use when accessing the Spire GraphQL API.",
@@ -42,5 +415,17 @@ func (SourceConfig) Parameters() map[string]config.Parameter {
 				config.ValidationRequired{},
 			},
 		},
+		SourceConfigWatchlistInterval: {
+			Default:     "30s",
+			Description: "Interval is the time between two polls of the vessels on the\nwatchlist.",
+			Type:        config.ParameterTypeDuration,
+			Validations: []config.Validation{},
+		},
+		SourceConfigWatchlistPath: {
+			Default:     "",
+			Description: "Path is a CSV file listing vessels that are polled more frequently.\nEach line contains \"mmsi\" or \"imo\", the number and optional labels.\nThe file is reloaded when it changes.",
+			Type:        config.ParameterTypeString,
+			Validations: []config.Validation{},
+		},
 	}
 }
@@ -0,0 +1,145 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	PollModeBackoff  = "backoff"
+	PollModeInterval = "interval"
+	PollModeCron     = "cron"
+	PollModeAdaptive = "adaptive"
+)
+
+type PollConfig struct {
+	// Mode is how polls for new updates are scheduled once a result set was
+	// read completely: "backoff" leaves the pacing to the SDK's backoff,
+	// "interval" polls every Interval, "cron" polls according to Cron and
+	// "adaptive" adjusts the interval to the number of updated vessels.
+	Mode string `json:"mode" default:"backoff" validate:"inclusion=backoff|interval|cron|adaptive"`
+	// Interval is the time between the start of two polls. In adaptive mode
+	// it is the initial interval.
+	Interval time.Duration `json:"interval" default:"1m"`
+	// Cron is a cron expression with 5 fields or a descriptor like "@hourly".
+	Cron string `json:"cron"`
+	// MinInterval is the shortest interval in adaptive mode.
+	MinInterval time.Duration `json:"minInterval" default:"10s"`
+	// MaxInterval is the longest interval in adaptive mode.
+	MaxInterval time.Duration `json:"maxInterval" default:"10m"`
+	// TargetCount is the number of updated vessels per poll the adaptive
+	// mode aims for.
+	TargetCount int `json:"targetCount" default:"1000"`
+}
+
+// pollSchedule determines when the next result set is requested.
+type pollSchedule struct {
+	mode     string
+	cron     cron.Schedule
+	interval time.Duration
+	min, max time.Duration
+	target   int
+}
+
+// newPollSchedule returns the schedule for the configuration, or nil if the
+// pacing is left to the SDK.
+func newPollSchedule(cfg PollConfig) (*pollSchedule, error) {
+	s := &pollSchedule{
+		mode:     cfg.Mode,
+		interval: cfg.Interval,
+		min:      cfg.MinInterval,
+		max:      cfg.MaxInterval,
+		target:   cfg.TargetCount,
+	}
+	switch cfg.Mode {
+	case PollModeBackoff, "":
+		return nil, nil
+	case PollModeInterval:
+		if cfg.Interval <= 0 {
+			return nil, errors.New("poll interval must be positive")
+		}
+	case PollModeCron:
+		sched, err := cron.ParseStandard(cfg.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", cfg.Cron, err)
+		}
+		s.cron = sched
+	case PollModeAdaptive:
+		switch {
+		case cfg.MinInterval <= 0 || cfg.MaxInterval < cfg.MinInterval:
+			return nil, errors.New("adaptive polling requires 0 < min interval <= max interval")
+		case cfg.TargetCount <= 0:
+			return nil, errors.New("adaptive polling requires a positive target count")
+		}
+		s.interval = min(max(cfg.Interval, s.min), s.max)
+	default:
+		return nil, fmt.Errorf("unknown poll mode %q", cfg.Mode)
+	}
+	return s, nil
+}
+
+// next returns the time of the poll following the one that started at
+// started and returned count updated vessels.
+func (s *pollSchedule) next(started time.Time, count int) time.Time {
+	switch s.mode {
+	case PollModeCron:
+		return s.cron.Next(started)
+	case PollModeAdaptive:
+		// scale the interval so the next poll returns about the target
+		// count, assuming updates arrive at a constant rate
+		if count == 0 {
+			s.interval *= 2
+		} else {
+			s.interval = time.Duration(float64(s.interval) * float64(s.target) / float64(count))
+		}
+		s.interval = min(max(s.interval, s.min), s.max)
+	}
+	return started.Add(s.interval)
+}
+
+// schedulePoll is called at the end of a complete result set and determines
+// when the next one is requested.
+func (it *Iterator) schedulePoll(ctx context.Context) {
+	if it.schedule == nil {
+		return
+	}
+	it.nextPoll = it.schedule.next(it.sweepStarted, it.sweepNodes)
+	sdk.Logger(ctx).Info().
+		Int("updatedVessels", it.sweepNodes).
+		Time("nextPoll", it.nextPoll).
+		Msg("Next poll scheduled")
+}
+
+// waitForPoll blocks until the next scheduled poll is due and reports
+// whether it is. It returns early if the watchlist is due before.
+func (it *Iterator) waitForPoll(ctx context.Context) (bool, error) {
+	until, due := it.nextPoll, true
+	if it.watchlist != nil && it.watchlist.next.Before(until) {
+		until, due = it.watchlist.next, false
+	}
+	if d := time.Until(until); d > 0 {
+		if err := sleep(ctx, d); err != nil {
+			return false, err
+		}
+	}
+	return due, nil
+}
@@ -0,0 +1,68 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestPollSchedule(t *testing.T) {
+	started := time.Date(2024, 1, 1, 12, 7, 30, 0, time.UTC)
+
+	t.Run("Backoff", func(t *testing.T) {
+		is := is.New(t)
+		s, err := newPollSchedule(PollConfig{Mode: PollModeBackoff})
+		is.NoErr(err)
+		is.True(s == nil)
+	})
+
+	t.Run("Interval", func(t *testing.T) {
+		is := is.New(t)
+		s, err := newPollSchedule(PollConfig{Mode: PollModeInterval, Interval: time.Minute})
+		is.NoErr(err)
+		is.Equal(s.next(started, 10), started.Add(time.Minute))
+	})
+
+	t.Run("Cron", func(t *testing.T) {
+		is := is.New(t)
+		s, err := newPollSchedule(PollConfig{Mode: PollModeCron, Cron: "*/15 * * * *"})
+		is.NoErr(err)
+		is.Equal(s.next(started, 10), time.Date(2024, 1, 1, 12, 15, 0, 0, time.UTC))
+
+		_, err = newPollSchedule(PollConfig{Mode: PollModeCron, Cron: "every minute"})
+		is.True(err != nil)
+	})
+
+	t.Run("Adaptive", func(t *testing.T) {
+		is := is.New(t)
+		s, err := newPollSchedule(PollConfig{
+			Mode:        PollModeAdaptive,
+			Interval:    time.Minute,
+			MinInterval: 10 * time.Second,
+			MaxInterval: 4 * time.Minute,
+			TargetCount: 100,
+		})
+		is.NoErr(err)
+
+		is.Equal(s.next(started, 200), started.Add(30*time.Second)) // busy, poll more often
+		is.Equal(s.next(started, 50), started.Add(time.Minute))     // quiet, poll less often
+		is.Equal(s.next(started, 10000), started.Add(10*time.Second))
+		is.Equal(s.next(started, 0), started.Add(20*time.Second))
+		is.Equal(s.next(started, 1), started.Add(4*time.Minute))
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+)
+
+// Position is the position of a single record produced by the source.
+type Position struct {
+	// Cursor is the Spire cursor after which the page containing the record
+	// starts. It is empty for the first page of a result set.
+	Cursor string `json:"cursor"`
+	// Offset is the index of the record within its page.
+	Offset int `json:"offset"`
+	// Watermark is the latest vessel update timestamp read up to and
+	// including the record.
+	Watermark time.Time `json:"watermark"`
+	// StartTime is the start time the result set containing the record was
+	// queried with. Cursors are only valid for the same start time.
+	StartTime time.Time `json:"startTime"`
+	// WindowEnd is the end of the backfill window containing the record. It
+	// is zero if the record was not read as part of a backfill.
+	WindowEnd time.Time `json:"windowEnd,omitzero"`
+	// Tombstone numbers the delete records emitted for stale vessels after a
+	// result set, so each of them has its own position.
+	Tombstone int `json:"tombstone,omitempty"`
+	// Watched numbers the records of watched vessels, which otherwise carry
+	// the position of the preceding record, so each of them has its own
+	// position.
+	Watched int `json:"watched,omitempty"`
+}
+
+// ParsePosition parses a record position. Positions written by older versions
+// of the connector only contain the end cursor of the page the record was read
+// from, reading resumes after that page.
+func ParsePosition(p opencdc.Position) (Position, error) {
+	if len(p) == 0 {
+		return Position{Offset: -1}, nil
+	}
+	if p[0] != '{' {
+		return Position{Cursor: string(p), Offset: -1}, nil
+	}
+
+	var pos Position
+	if err := json.Unmarshal(p, &pos); err != nil {
+		return Position{}, fmt.Errorf("invalid position %q: %w", string(p), err)
+	}
+	return pos, nil
+}
+
+// ToRecordPosition serializes the position so it can be attached to a record.
+func (p Position) ToRecordPosition() opencdc.Position {
+	b, err := json.Marshal(p)
+	if err != nil {
+		// Position only contains types that can always be marshalled.
+		panic(fmt.Errorf("error occurred marshalling position: %w", err))
+	}
+	return b
+}
@@ -14,10 +14,71 @@
 
 package ais
 
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	StartFromNow      = "now"
+	StartFromEarliest = "earliest"
+)
+
+// earliestStart is the start time of queries reading from the earliest
+// update.
+var earliestStart = time.Unix(0, 0).UTC()
+
+// declaresVariable reports whether the operation of the query declares the
+// variable with the given name.
+func declaresVariable(query, name string) bool {
+	header, _, _ := strings.Cut(query, "{")
+	return regexp.MustCompile(`\$` + regexp.QuoteMeta(name) + `\b`).MatchString(header)
+}
+
+// parseStartFrom resolves the startFrom setting relative to now. It accepts
+// "now", "earliest", a negative duration like "-6h" or an RFC3339 timestamp.
+func parseStartFrom(startFrom string, now time.Time) (time.Time, error) {
+	switch {
+	case startFrom == StartFromNow:
+		return now, nil
+	case startFrom == StartFromEarliest:
+		return earliestStart, nil
+	case strings.HasPrefix(startFrom, "-"):
+		d, err := time.ParseDuration(startFrom)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative start %q: %w", startFrom, err)
+		}
+		return now.Add(d), nil
+	default:
+		t, err := time.Parse(time.RFC3339, startFrom)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("start %q is neither %q, %q, a negative duration nor an RFC3339 timestamp", startFrom, StartFromNow, StartFromEarliest)
+		}
+		return t, nil
+	}
+}
+
 func vesselQuery() string {
+	// the selection of all fields is always valid
+	q, _ := vesselQueryFields(nil, nil)
+	return q
+}
+
+// vesselQueryFields returns the default query selecting only the given fields
+// of the vessel nodes, see selectionSet.
+func vesselQueryFields(fields, excludeFields []string) (string, error) {
+	selection, err := selectionSet(fields, excludeFields)
+	if err != nil {
+		return "", err
+	}
+
 	return `
-	query ($first: Int!, $after: String){
-	        vessels(first:$first, after:$after, lastPositionUpdate: { startTime: "2023-11-12T21:00:48.768Z" }) {
+	query ($first: Int!, $after: String, $startTime: DateTime!, $endTime: DateTime){
+	        vessels(first:$first, after:$after, lastPositionUpdate: { startTime: $startTime, endTime: $endTime }) {
 				pageInfo {
 				 hasNextPage
 				 endCursor
@@ -27,52 +88,92 @@ func vesselQuery() string {
 				relation
 			   }
 			   nodes {
-				 id
-				 updateTimestamp
-				 staticData {
-				   aisClass
-				   flag
-				   name
-				   callsign
-				   timestamp
-				   updateTimestamp
-				   shipType
-				   shipSubType
-				   mmsi
-				   imo
-				   callsign
-				   dimensions {
-					 a
-					 b
-					 c
-					 d
-					 width
-					 length
-				   }
-				 }
-				 lastPositionUpdate {
-				   accuracy
-				   collectionType
-				   course
-				   heading
-				   latitude
-				   longitude
-				   maneuver
-				   navigationalStatus
-				   rot
-				   speed
-				   timestamp
-				   updateTimestamp
-				 }
-				 currentVoyage {
-				   destination
-				   draught
-				   eta
-				   timestamp
-				   updateTimestamp
-				 }
-			   }
+` + selection + `			   }
 			 }
 	    }
-	`
+	`, nil
+}
+
+// requiredFields are always selected, records can't be built without them.
+var requiredFields = []string{"id", "updateTimestamp"}
+
+// selectionSet generates the GraphQL selection set for vessel nodes from the
+// Node model. Fields are dot separated JSON paths such as "staticData.mmsi",
+// a path selects all fields below it and a trailing ".*" is allowed (e.g.
+// "lastPositionUpdate.*"). No fields select everything, excluded fields take
+// precedence over selected ones.
+func selectionSet(fields, excludeFields []string) (string, error) {
+	leaves := modelFields("", reflect.TypeOf(Node{}))
+
+	for _, pattern := range slices.Concat(fields, excludeFields) {
+		if !slices.ContainsFunc(leaves, func(leaf string) bool { return matchField(pattern, leaf) }) {
+			return "", fmt.Errorf("unknown field %q", pattern)
+		}
+	}
+
+	var selected []string
+	for _, leaf := range leaves {
+		include := len(fields) == 0 || slices.ContainsFunc(fields, func(p string) bool { return matchField(p, leaf) })
+		exclude := slices.ContainsFunc(excludeFields, func(p string) bool { return matchField(p, leaf) })
+		if slices.Contains(requiredFields, leaf) || (include && !exclude) {
+			selected = append(selected, leaf)
+		}
+	}
+
+	var sb strings.Builder
+	writeSelection(&sb, selected, "", 4)
+	return sb.String(), nil
+}
+
+// modelFields returns the JSON paths of all leaf fields of a struct type.
+func modelFields(prefix string, t reflect.Type) []string {
+	var out []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + name
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			out = append(out, modelFields(path+".", ft)...)
+			continue
+		}
+		out = append(out, path)
+	}
+	return out
+}
+
+func matchField(pattern, path string) bool {
+	pattern = strings.TrimSuffix(pattern, ".*")
+	return pattern == "*" || pattern == path || strings.HasPrefix(path, pattern+".")
+}
+
+// writeSelection writes the selected paths below prefix as nested GraphQL
+// fields, keeping the order of the model.
+func writeSelection(sb *strings.Builder, selected []string, prefix string, depth int) {
+	indent := strings.Repeat("\t", depth)
+	var written []string
+	for _, path := range selected {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		name, _, nested := strings.Cut(strings.TrimPrefix(path, prefix), ".")
+		if slices.Contains(written, name) {
+			continue
+		}
+		written = append(written, name)
+
+		if !nested {
+			sb.WriteString(indent + name + "\n")
+			continue
+		}
+		sb.WriteString(indent + name + " {\n")
+		writeSelection(sb, selected, prefix+name+".", depth+1)
+		sb.WriteString(indent + "}\n")
+	}
 }
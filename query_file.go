@@ -0,0 +1,279 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// queryFiles are the GraphQL files the query is loaded from. The files are
+// concatenated into a single document, so fragments defined in one file can be
+// used by operations in another.
+type queryFiles struct {
+	queryFile     string
+	fragmentFiles []string
+	// modTimes contains the modification time of each loaded file.
+	modTimes map[string]time.Time
+}
+
+func newQueryFiles(queryFile string, fragmentFiles []string) *queryFiles {
+	return &queryFiles{
+		queryFile:     queryFile,
+		fragmentFiles: fragmentFiles,
+	}
+}
+
+// paths returns the query file followed by all files matching the fragment
+// file patterns.
+func (q *queryFiles) paths() ([]string, error) {
+	paths := []string{q.queryFile}
+	for _, pattern := range q.fragmentFiles {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fragment file pattern %q: %w", pattern, err)
+		}
+		for _, m := range matches {
+			if !slices.Contains(paths, m) {
+				paths = append(paths, m)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// Load reads and concatenates all files and remembers their modification
+// times.
+func (q *queryFiles) Load() (string, error) {
+	paths, err := q.paths()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	modTimes := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("error reading query file: %w", err)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("error reading query file: %w", err)
+		}
+		modTimes[path] = info.ModTime()
+		sb.Write(b)
+		sb.WriteString("\n")
+	}
+
+	q.modTimes = modTimes
+	return sb.String(), nil
+}
+
+// Changed reports whether any of the files was modified, added or removed
+// since they were last loaded.
+func (q *queryFiles) Changed() (bool, error) {
+	paths, err := q.paths()
+	if err != nil {
+		return false, err
+	}
+	if len(paths) != len(q.modTimes) {
+		return true, nil
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, fmt.Errorf("error checking query file: %w", err)
+		}
+		if modTime, ok := q.modTimes[path]; !ok || !modTime.Equal(info.ModTime()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type graphqlDefinition struct {
+	kind string // query, mutation, subscription or fragment
+	name string
+	text string
+}
+
+var fragmentSpread = regexp.MustCompile(`\.\.\.\s*([_A-Za-z][_0-9A-Za-z]*)`)
+
+// selectOperation returns the operation with the given name together with all
+// fragments it uses, directly or through other fragments. If name is empty the
+// document must contain exactly one operation.
+func selectOperation(document, name string) (string, error) {
+	defs, err := parseDefinitions(document)
+	if err != nil {
+		return "", err
+	}
+
+	var op *graphqlDefinition
+	fragments := make(map[string]graphqlDefinition)
+	var operations []string
+	for i, d := range defs {
+		if d.kind == "fragment" {
+			fragments[d.name] = d
+			continue
+		}
+		operations = append(operations, d.name)
+		if d.name == name || (name == "" && op == nil) {
+			op = &defs[i]
+		}
+	}
+	switch {
+	case op == nil && name != "":
+		return "", fmt.Errorf("operation %q not found, available operations: %v", name, operations)
+	case op == nil:
+		return "", errors.New("query document contains no operation")
+	case name == "" && len(operations) > 1:
+		return "", fmt.Errorf("query document contains %d operations, select one using the operation name", len(operations))
+	}
+
+	used := make(map[string]bool)
+	queue := []string{op.text}
+	for len(queue) > 0 {
+		text := queue[0]
+		queue = queue[1:]
+		for _, m := range fragmentSpread.FindAllStringSubmatch(text, -1) {
+			spread := m[1]
+			if spread == "on" || used[spread] {
+				continue // inline fragment or already included
+			}
+			f, ok := fragments[spread]
+			if !ok {
+				return "", fmt.Errorf("fragment %q used by operation %q is not defined", spread, op.name)
+			}
+			used[spread] = true
+			queue = append(queue, f.text)
+		}
+	}
+
+	parts := []string{op.text}
+	for _, d := range defs {
+		if d.kind == "fragment" && used[d.name] {
+			parts = append(parts, d.text)
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// parseDefinitions splits a GraphQL document into its top level definitions.
+func parseDefinitions(document string) ([]graphqlDefinition, error) {
+	var defs []graphqlDefinition
+	i := 0
+	for {
+		i = skipIgnored(document, i)
+		if i >= len(document) {
+			return defs, nil
+		}
+
+		start := i
+		open := strings.IndexByte(document[i:], '{')
+		if open < 0 {
+			return nil, fmt.Errorf("invalid query document: expected '{' after %q", strings.TrimSpace(document[i:]))
+		}
+		header := strings.Fields(strings.NewReplacer("(", " (", "@", " @").Replace(document[i : i+open]))
+
+		end, err := matchBrace(document, i+open)
+		if err != nil {
+			return nil, err
+		}
+		i = end + 1
+
+		d := graphqlDefinition{kind: "query", text: strings.TrimSpace(document[start:i])}
+		if len(header) > 0 {
+			d.kind = header[0]
+		}
+		if len(header) > 1 && !strings.HasPrefix(header[1], "(") && !strings.HasPrefix(header[1], "@") {
+			d.name = header[1]
+		}
+		switch d.kind {
+		case "query", "mutation", "subscription":
+		case "fragment":
+			if d.name == "" {
+				return nil, errors.New("invalid query document: fragment without name")
+			}
+		default:
+			return nil, fmt.Errorf("invalid query document: unexpected definition %q", d.kind)
+		}
+		defs = append(defs, d)
+	}
+}
+
+// skipIgnored skips whitespace, commas and comments.
+func skipIgnored(s string, i int) int {
+	for i < len(s) {
+		switch {
+		case s[i] == '#':
+			nl := strings.IndexByte(s[i:], '\n')
+			if nl < 0 {
+				return len(s)
+			}
+			i += nl + 1
+		case strings.IndexByte(" \t\r\n,", s[i]) >= 0:
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// matchBrace returns the index of the brace closing the one at open, skipping
+// strings and comments.
+func matchBrace(s string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '#':
+			nl := strings.IndexByte(s[i:], '\n')
+			if nl < 0 {
+				i = len(s)
+				continue
+			}
+			i += nl
+		case '"':
+			if strings.HasPrefix(s[i:], `"""`) {
+				end := strings.Index(s[i+3:], `"""`)
+				if end < 0 {
+					return 0, errors.New("invalid query document: unterminated block string")
+				}
+				i += end + 5
+				continue
+			}
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, errors.New("invalid query document: unbalanced braces")
+}
@@ -0,0 +1,118 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+const testOperations = `
+# positions only
+query Positions($first: Int!, $after: String) {
+  vessels(first: $first, after: $after) {
+    nodes { ...VesselID lastPositionUpdate { latitude longitude } }
+  }
+}
+
+query Voyages($first: Int!, $after: String) {
+  vessels(first: $first, after: $after) {
+    nodes { ...VesselID currentVoyage { destination description: eta } }
+  }
+}
+`
+
+const testFragments = `
+fragment VesselID on Vessel {
+  id
+  staticData { ...Identity }
+}
+
+fragment Identity on StaticData {
+  mmsi
+  imo
+  name @include(if: true) # "}" in a comment
+}
+
+fragment Unused on Vessel { id }
+`
+
+func TestQueryFiles(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	queryFile := filepath.Join(dir, "operations.graphql")
+	is.NoErr(os.WriteFile(queryFile, []byte(testOperations), 0o600))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "fragments.graphql"), []byte(testFragments), 0o600))
+
+	files := newQueryFiles(queryFile, []string{filepath.Join(dir, "*.graphql")})
+	document, err := files.Load()
+	is.NoErr(err)
+
+	query, err := selectOperation(document, "Positions")
+	is.NoErr(err)
+	defs, err := parseDefinitions(query)
+	is.NoErr(err)
+	is.Equal(len(defs), 3)
+	is.Equal(defs[0].name, "Positions")
+	is.Equal(defs[1].name, "VesselID")
+	is.Equal(defs[2].name, "Identity")
+
+	_, err = selectOperation(document, "")
+	is.True(err != nil) // two operations, no name
+	_, err = selectOperation(document, "Missing")
+	is.True(err != nil)
+	_, err = selectOperation(testOperations, "Positions")
+	is.True(err != nil) // fragments not loaded
+
+	changed, err := files.Changed()
+	is.NoErr(err)
+	is.True(!changed)
+
+	future := time.Now().Add(time.Minute)
+	is.NoErr(os.Chtimes(queryFile, future, future))
+	changed, err = files.Changed()
+	is.NoErr(err)
+	is.True(changed)
+}
+
+func TestIterator_ReloadQuery(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	queryFile := filepath.Join(dir, "query.graphql")
+	is.NoErr(os.WriteFile(queryFile, []byte(`query Positions { vessels { nodes { id } } }`), 0o600))
+
+	it, err := NewIterator(&MockGraphQLClient{}, "test-token", "", 100, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{QueryFile: queryFile}))
+
+	// an invalid query keeps the previous one
+	is.NoErr(os.WriteFile(queryFile, []byte(`query Positions { vessels { nodes { id }`), 0o600))
+	future := time.Now().Add(time.Minute)
+	is.NoErr(os.Chtimes(queryFile, future, future))
+	it.reloadQuery(t.Context())
+	is.Equal(it.query, "query Positions { vessels { nodes { id } } }\n")
+
+	is.NoErr(os.WriteFile(queryFile, []byte(`query Voyages { vessels { nodes { currentVoyage { eta } } } }`), 0o600))
+	future = future.Add(time.Minute)
+	is.NoErr(os.Chtimes(queryFile, future, future))
+	it.reloadQuery(t.Context())
+	query, err := it.renderer.Render(QueryData{})
+	is.NoErr(err)
+	is.Equal(query, "query Voyages { vessels { nodes { currentVoyage { eta } } } }")
+}
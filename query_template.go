@@ -0,0 +1,124 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// QueryData is the data available in query templates.
+type QueryData struct {
+	// Watermark is the latest vessel update timestamp read so far.
+	Watermark time.Time
+	// Now is the time the query is rendered.
+	Now time.Time
+	// WindowStart is the start time of the current result set.
+	WindowStart time.Time
+	// WindowEnd is the end of the current backfill window, zero otherwise.
+	WindowEnd time.Time
+	// Vars contains the queryVariables from the configuration.
+	Vars map[string]string
+}
+
+var queryFuncs = template.FuncMap{
+	"rfc3339": func(t time.Time) string {
+		return t.Format(time.RFC3339Nano)
+	},
+	"ago": func(d string, t time.Time) (time.Time, error) {
+		dur, err := time.ParseDuration(d)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.Add(-dur), nil
+	},
+}
+
+// queryRenderer turns the configured query into the query sent with a request.
+type queryRenderer struct {
+	tmpl          *template.Template
+	vars          map[string]string
+	operationName string
+	// selectOperation extracts the operation and the fragments it uses from
+	// the document, it is enabled for query files and named operations.
+	selectOperation bool
+}
+
+func newQueryRenderer(query string, vars map[string]string, operationName string, selectOp bool) (*queryRenderer, error) {
+	tmpl, err := parseQueryTemplate(query, vars)
+	if err != nil {
+		return nil, err
+	}
+	r := &queryRenderer{
+		tmpl:            tmpl,
+		vars:            vars,
+		operationName:   operationName,
+		selectOperation: selectOp || operationName != "",
+	}
+
+	now := time.Now()
+	if _, err := r.Render(QueryData{Watermark: now, Now: now, WindowStart: now, WindowEnd: now}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Render renders the query template and selects the operation to send.
+func (r *queryRenderer) Render(data QueryData) (string, error) {
+	data.Vars = r.vars
+	query, err := renderQuery(r.tmpl, data)
+	if err != nil {
+		return "", err
+	}
+	if !r.selectOperation {
+		return query, nil
+	}
+	return selectOperation(query, r.operationName)
+}
+
+// parseQueryTemplate parses the query as a text/template and renders it once
+// with sample data, so errors such as unknown variables surface early.
+func parseQueryTemplate(query string, vars map[string]string) (*template.Template, error) {
+	tmpl, err := template.New("query").
+		Funcs(queryFuncs).
+		Option("missingkey=error").
+		Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query template: %w", err)
+	}
+
+	now := time.Now()
+	_, err = renderQuery(tmpl, QueryData{
+		Watermark:   now,
+		Now:         now,
+		WindowStart: now,
+		WindowEnd:   now,
+		Vars:        vars,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+func renderQuery(tmpl *template.Template, data QueryData) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("error rendering query template: %w", err)
+	}
+	return sb.String(), nil
+}
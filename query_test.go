@@ -0,0 +1,151 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+)
+
+func TestParseStartFrom(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		startFrom string
+		want      time.Time
+		wantErr   bool
+	}{
+		{startFrom: "now", want: now},
+		{startFrom: "earliest", want: time.Unix(0, 0).UTC()},
+		{startFrom: "-6h", want: now.Add(-6 * time.Hour)},
+		{startFrom: "2023-11-12T21:00:48.768Z", want: time.Date(2023, 11, 12, 21, 0, 48, 768000000, time.UTC)},
+		{startFrom: "-six hours", wantErr: true},
+		{startFrom: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.startFrom, func(t *testing.T) {
+			is := is.New(t)
+			got, err := parseStartFrom(tt.startFrom, now)
+			if tt.wantErr {
+				is.True(err != nil)
+				return
+			}
+			is.NoErr(err)
+			is.True(got.Equal(tt.want))
+		})
+	}
+}
+
+func TestQueryTemplate(t *testing.T) {
+	is := is.New(t)
+	query := `vessels(lastPositionUpdate: { startTime: "{{ .WindowStart | rfc3339 }}", endTime: "{{ ago "1h" .Now | rfc3339 }}" }, flag: "{{ .Vars.flag }}")`
+
+	tmpl, err := parseQueryTemplate(query, map[string]string{"flag": "NL"})
+	is.NoErr(err)
+
+	got, err := renderQuery(tmpl, QueryData{
+		Now:         time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		WindowStart: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Vars:        map[string]string{"flag": "NL"},
+	})
+	is.NoErr(err)
+	is.Equal(got, `vessels(lastPositionUpdate: { startTime: "2024-05-01T00:00:00Z", endTime: "2024-05-01T11:00:00Z" }, flag: "NL")`)
+
+	_, err = parseQueryTemplate(query, nil)
+	is.True(err != nil) // .Vars.flag is not configured
+
+	_, err = parseQueryTemplate(`{{ .Unknown }}`, nil)
+	is.True(err != nil)
+
+	_, err = parseQueryTemplate(vesselQuery(), nil)
+	is.NoErr(err)
+}
+
+func TestSelectionSet(t *testing.T) {
+	is := is.New(t)
+
+	got, err := selectionSet([]string{"staticData.mmsi", "lastPositionUpdate.*"}, []string{"lastPositionUpdate.timestamp", "lastPositionUpdate.updateTimestamp"})
+	is.NoErr(err)
+	is.Equal(got, `				id
+				updateTimestamp
+				staticData {
+					mmsi
+				}
+				lastPositionUpdate {
+					accuracy
+					collectionType
+					course
+					heading
+					latitude
+					longitude
+					maneuver
+					navigationalStatus
+					rot
+					speed
+				}
+`)
+
+	all, err := selectionSet(nil, nil)
+	is.NoErr(err)
+	for _, field := range []string{"aisClass", "dimensions {", "width", "navigationalStatus", "destination", "eta"} {
+		is.True(strings.Contains(all, field))
+	}
+
+	_, err = selectionSet([]string{"staticData.unknown"}, nil)
+	is.True(err != nil)
+	_, err = selectionSet(nil, []string{"voyage"})
+	is.True(err != nil)
+}
+
+func TestIterator_DefaultStartTime(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		want  any
+	}{
+		{"DefaultQuery", vesselQuery(), "1970-01-01T00:00:00Z"},
+		{"CustomQuery", `query ($first: Int!, $after: String) { vessels { nodes { id } } }`, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			var vars map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					Variables map[string]any `json:"variables"`
+				}
+				is.NoErr(json.NewDecoder(r.Body).Decode(&req))
+				vars = req.Variables
+				_, _ = w.Write([]byte(`{"data":{"vessels":{"nodes":[]}}}`))
+			}))
+			defer server.Close()
+
+			it, err := NewIterator(graphql.NewClient(server.URL), "test-token", tc.query, 100, nil)
+			is.NoErr(err)
+			_, _, err = it.fetchPage(context.Background(), "")
+			is.NoErr(err)
+			is.Equal(vars["startTime"], tc.want)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import "math"
+
+// Turn states of a decoded rate of turn.
+const (
+	TurnStateNotTurning   = "NOT_TURNING"
+	TurnStateLeft         = "TURNING_LEFT"
+	TurnStateRight        = "TURNING_RIGHT"
+	TurnStateLeftFast     = "TURNING_LEFT_FAST"
+	TurnStateRightFast    = "TURNING_RIGHT_FAST"
+	TurnStateNotAvailable = "NOT_AVAILABLE"
+)
+
+const (
+	rotIndicatorNotAvailable = -128
+	rotIndicatorFast         = 127
+	rotIndicatorMax          = 126
+	rotFactor                = 4.733
+)
+
+// rateOfTurn is the decoded AIS rate of turn indicator.
+type rateOfTurn struct {
+	// DegreesPerMinute is negative when turning left. It is nil if the rate
+	// is not quantified.
+	DegreesPerMinute *float64 `json:"degreesPerMinute"`
+	State            string   `json:"state"`
+	// AtLeast is set if the indicator is at its maximum, so the actual rate
+	// may be higher.
+	AtLeast bool `json:"atLeast,omitempty"`
+}
+
+// decodeRateOfTurn converts the AIS rate of turn indicator to degrees per
+// minute using ROT_AIS = 4.733 * sqrt(ROT_sensor). The indicators ±127 mean
+// turning faster than 5° per 30 seconds without a turn indicator and -128
+// means no turn information is available.
+func decodeRateOfTurn(indicator float64) rateOfTurn {
+	switch {
+	case indicator == rotIndicatorNotAvailable || math.Abs(indicator) > rotIndicatorFast:
+		return rateOfTurn{State: TurnStateNotAvailable}
+	case indicator == rotIndicatorFast:
+		return rateOfTurn{State: TurnStateRightFast}
+	case indicator == -rotIndicatorFast:
+		return rateOfTurn{State: TurnStateLeftFast}
+	}
+
+	rate := math.Copysign(math.Pow(indicator/rotFactor, 2), indicator)
+	rot := rateOfTurn{
+		DegreesPerMinute: &rate,
+		AtLeast:          math.Abs(indicator) >= rotIndicatorMax,
+	}
+	switch {
+	case indicator > 0:
+		rot.State = TurnStateRight
+	case indicator < 0:
+		rot.State = TurnStateLeft
+	default:
+		rot.State = TurnStateNotTurning
+	}
+	return rot
+}
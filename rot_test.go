@@ -0,0 +1,90 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+)
+
+func TestDecodeRateOfTurn(t *testing.T) {
+	testCases := []struct {
+		indicator float64
+		state     string
+		rate      float64 // NaN if not quantified
+		atLeast   bool
+	}{
+		{indicator: 0, state: TurnStateNotTurning, rate: 0},
+		{indicator: 4.733, state: TurnStateRight, rate: 1},
+		{indicator: -47.33, state: TurnStateLeft, rate: -100},
+		{indicator: 126, state: TurnStateRight, rate: 708.7, atLeast: true},
+		{indicator: -126, state: TurnStateLeft, rate: -708.7, atLeast: true},
+		{indicator: 127, state: TurnStateRightFast, rate: math.NaN()},
+		{indicator: -127, state: TurnStateLeftFast, rate: math.NaN()},
+		{indicator: -128, state: TurnStateNotAvailable, rate: math.NaN()},
+		{indicator: 300, state: TurnStateNotAvailable, rate: math.NaN()},
+	}
+	for _, tc := range testCases {
+		is := is.New(t)
+		rot := decodeRateOfTurn(tc.indicator)
+		is.Equal(rot.State, tc.state)
+		is.Equal(rot.AtLeast, tc.atLeast)
+		if math.IsNaN(tc.rate) {
+			is.Equal(rot.DegreesPerMinute, nil)
+			continue
+		}
+		is.True(rot.DegreesPerMinute != nil)
+		is.True(math.Abs(*rot.DegreesPerMinute-tc.rate) < 0.1)
+	}
+}
+
+func TestIterator_DeriveRateOfTurn(t *testing.T) {
+	is := is.New(t)
+
+	client := &MockGraphQLClient{
+		RunFn: func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+			arg := resp.(*struct{ Vessels Vessels })
+			vessel := testVessel
+			vessel.LastPositionUpdate.Rot = -128
+			arg.Vessels.Nodes = []Node{vessel}
+			return nil
+		},
+	}
+	it, err := NewIterator(client, "test-token", vesselQuery(), 1, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{
+		Derive: DeriveConfig{RateOfTurn: true},
+		// the turn state is derived before the sentinel is normalized
+		Normalize: NormalizeConfig{Fields: []string{"lastPositionUpdate.rot"}, Mode: NormalizeModeNull},
+	}))
+
+	record, err := it.Next(context.Background())
+	is.NoErr(err)
+
+	var got map[string]any
+	is.NoErr(json.Unmarshal(record.Payload.After.Bytes(), &got))
+	is.Equal(got["derived"], map[string]any{
+		"rateOfTurn": map[string]any{
+			"degreesPerMinute": nil,
+			"state":            TurnStateNotAvailable,
+		},
+	})
+	is.Equal(got["lastPositionUpdate"].(map[string]any)["rot"], nil)
+}
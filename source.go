@@ -18,7 +18,12 @@ package ais
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/conduitio/conduit-commons/config"
 	"github.com/conduitio/conduit-commons/lang"
@@ -38,6 +43,15 @@ func (ic SourceIteratorCreator) NewIterator(client GraphQLClient, token string,
 	return NewIterator(client, token, query, batchSize, p)
 }
 
+// ErrEndOfData is returned by Read in run once mode after the complete result
+// set was read. It wraps context.Canceled, which the SDK treats as the source
+// gracefully running out of records.
+var ErrEndOfData = fmt.Errorf("end of data: %w", context.Canceled)
+
+// teardownTimeout bounds how long Teardown waits for in-flight reads if the
+// context passed to it has no deadline.
+const teardownTimeout = 30 * time.Second
+
 type Source struct {
 	sdk.UnimplementedSource
 
@@ -45,6 +59,16 @@ type Source struct {
 	iterator             *Iterator
 	iteratorCreator      IteratorCreator
 	startQueryFromCursor bool
+	// backedOff is set after Read asked the SDK to back off, the next Read
+	// starts a new result set in the backoff poll mode.
+	backedOff bool
+
+	httpClient *http.Client
+	// stop cancels all in-flight requests once Teardown is called.
+	stop     context.Context
+	stopFunc context.CancelFunc
+	inflight sync.WaitGroup
+	opened   time.Time
 }
 
 type SourceConfig struct {
@@ -52,7 +76,113 @@ type SourceConfig struct {
 	Config
 
 	// Query is the GraphQL Query to use when pulling data from the Spire API.
+	// It is a Go text/template rendered before every request, see QueryData
+	// for the available fields.
 	Query string `json:"query"`
+
+	// Fields limits the fields selected by the default query, e.g.
+	// "staticData.mmsi,lastPositionUpdate.*". The vessel ID and update
+	// timestamp are always selected.
+	Fields []string `json:"fields"`
+
+	// ExcludeFields removes fields from the selection of the default query.
+	ExcludeFields []string `json:"excludeFields"`
+
+	// TieredPolling splits polling of fast-changing positions from the
+	// slow-changing static data. It only applies to the default query.
+	TieredPolling TieredPollingConfig `json:"tieredPolling"`
+
+	// QueryFile is a .graphql file the query is loaded from instead of Query.
+	// The file is reloaded when it changes.
+	QueryFile string `json:"queryFile"`
+
+	// OperationName selects the operation to run if the query document
+	// contains several operations.
+	OperationName string `json:"operationName"`
+
+	// FragmentFiles are paths or glob patterns of .graphql files containing
+	// fragments shared between query files.
+	FragmentFiles []string `json:"fragmentFiles"`
+
+	// QueryVariables are user defined values available as .Vars in the query
+	// template.
+	QueryVariables map[string]string `json:"queryVariables"`
+
+	// Key configures how the record key is derived from a vessel.
+	Key KeyConfig `json:"key"`
+
+	// Payload configures the format of the record payload.
+	Payload PayloadConfig `json:"payload"`
+
+	// Collection routes records to collections based on vessel attributes.
+	Collection CollectionConfig `json:"collection"`
+
+	// Filter is a CEL expression evaluated against each vessel, only vessels
+	// for which it is true are emitted, e.g. `speed < 50`.
+	Filter string `json:"filter"`
+	// Exclude is a CEL expression evaluated against each vessel, vessels for
+	// which it is true are dropped, e.g. `speed > 12 && navigationalStatus
+	// == "MOORED"`.
+	Exclude string `json:"exclude"`
+
+	// Derive configures fields computed from the vessel data that are added
+	// to JSON payloads.
+	Derive DeriveConfig `json:"derive"`
+
+	// Normalize configures the normalization of AIS "not available" sentinel
+	// values.
+	Normalize NormalizeConfig `json:"normalize"`
+
+	// Errors configures how vessel nodes that cannot be converted to records
+	// are handled.
+	Errors ErrorsConfig `json:"errors"`
+
+	// ChangeTracking configures the metadata describing which fields of a
+	// vessel changed since it was last observed.
+	ChangeTracking ChangeTrackingConfig `json:"changeTracking"`
+
+	// StaleAfter is the time after which a vessel that did not appear in any
+	// result set is considered gone and a delete record is emitted for it. A
+	// value of 0 disables stale vessel detection.
+	StaleAfter time.Duration `json:"staleAfter" default:"0"`
+
+	// StatePath is the file in which the last acknowledged state of each
+	// vessel is persisted. If empty, the state is only kept in memory.
+	StatePath string `json:"statePath"`
+
+	// StartFrom is where reading starts if there is no saved position. It
+	// accepts "now", "earliest", a negative duration relative to now (e.g.
+	// "-6h") or an RFC3339 timestamp. It is passed to the query as the
+	// $startTime variable.
+	StartFrom string `json:"startFrom" default:"now"`
+
+	// ResetPosition ignores the saved position and starts reading from
+	// StartFrom.
+	ResetPosition bool `json:"resetPosition" default:"false"`
+
+	// Backfill configures a historical backfill that runs before polling
+	// for new updates.
+	Backfill BackfillConfig `json:"backfill"`
+
+	// Watchlist configures vessels of interest that are polled more
+	// frequently and labelled in the record metadata.
+	Watchlist WatchlistConfig `json:"watchlist"`
+
+	// Poll configures when new updates are polled once a result set was read
+	// completely.
+	Poll PollConfig `json:"poll"`
+
+	// RunOnce configures the source to stop after a single complete result set.
+	RunOnce RunOnceConfig `json:"runOnce"`
+}
+
+type RunOnceConfig struct {
+	// Enabled stops the source once the last page of the result set was read
+	// instead of polling for new updates.
+	Enabled bool `json:"enabled" default:"false"`
+	// EndTime is an optional RFC3339 timestamp, vessels updated after it are
+	// not emitted.
+	EndTime string `json:"endTime"`
 }
 
 func NewSource() sdk.Source {
@@ -77,21 +207,146 @@ func (s *Source) Configure(ctx context.Context, cfg config.Config) error {
 		return fmt.Errorf("invalid config: %w", err)
 	}
 
+	selectOp := false
+	if s.config.QueryFile != "" {
+		if s.config.Query != "" {
+			return fmt.Errorf("invalid config: only one of %q and %q can be set", SourceConfigQuery, SourceConfigQueryFile)
+		}
+		query, err := newQueryFiles(s.config.QueryFile, s.config.FragmentFiles).Load()
+		if err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		s.config.Query = query
+		selectOp = true
+	}
+
+	if s.config.TieredPolling.Enabled && s.config.Query != "" {
+		return fmt.Errorf("invalid config: %q only applies to the default query", SourceConfigTieredPollingEnabled)
+	}
+
+	if len(s.config.Fields) > 0 || len(s.config.ExcludeFields) > 0 || s.config.TieredPolling.Enabled {
+		if s.config.Query != "" {
+			return fmt.Errorf("invalid config: %q and %q only apply to the default query", SourceConfigFields, SourceConfigExcludeFields)
+		}
+		exclude := s.config.ExcludeFields
+		if s.config.TieredPolling.Enabled {
+			// static data is fetched by a separate, less frequent query
+			exclude = slices.Concat(exclude, staticFields)
+		}
+		query, err := vesselQueryFields(s.config.Fields, exclude)
+		if err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		s.config.Query = query
+	}
+
 	if s.config.Query == "" {
 		s.config.Query = vesselQuery()
 	}
 
+	renderer, err := newQueryRenderer(s.config.Query, s.config.QueryVariables, s.config.OperationName, selectOp)
+	if err != nil {
+		return fmt.Errorf("invalid config: %q: %w", SourceConfigQuery, err)
+	}
+
+	if _, err := newKeyBuilder(s.config.Key); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if _, err := newCollectionRouter(s.config.Collection); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if s.config.Payload.Encoding != PayloadEncodingJSON {
+		if s.config.Payload.Format == PayloadFormatGeoJSON {
+			return fmt.Errorf("invalid config: %q only applies to the %q payload format", SourceConfigPayloadEncoding, PayloadFormatJSON)
+		}
+		if _, err := newSchemaEncoder(s.config.Payload.Encoding); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	if err := s.config.Derive.validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if (s.config.Derive.enabled() || s.config.Derive.HullPolygon) && s.config.Payload.Encoding != PayloadEncodingJSON {
+		// binary schemas have no fields for derived data
+		return fmt.Errorf("invalid config: \"derive.*\" only applies to the %q payload encoding", PayloadEncodingJSON)
+	}
+
+	normalizer, err := newNormalizer(s.config.Normalize)
+	if err != nil {
+		return fmt.Errorf("invalid config: %q: %w", SourceConfigNormalizeFields, err)
+	}
+	if len(s.config.Normalize.Fields) > 0 && s.config.Payload.Encoding != PayloadEncodingJSON {
+		// binary schemas have no null values for normalized fields
+		return fmt.Errorf("invalid config: %q only applies to the %q payload encoding", SourceConfigNormalizeFields, PayloadEncodingJSON)
+	}
+
+	if s.config.Filter != "" {
+		if _, err := newNodeFilter(s.config.Filter, normalizer.nullable()); err != nil {
+			return fmt.Errorf("invalid config: %q: %w", SourceConfigFilter, err)
+		}
+	}
+	if s.config.Exclude != "" {
+		if _, err := newNodeFilter(s.config.Exclude, normalizer.nullable()); err != nil {
+			return fmt.Errorf("invalid config: %q: %w", SourceConfigExclude, err)
+		}
+	}
+
+	if _, err := parseStartFrom(s.config.StartFrom, time.Now()); err != nil {
+		return fmt.Errorf("invalid config: %q: %w", SourceConfigStartFrom, err)
+	}
+
+	if s.config.Backfill.Enabled {
+		if _, err := newBackfill(s.config.Backfill, time.Now()); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		if err := checkBackfillQuery(renderer); err != nil {
+			return fmt.Errorf("invalid config: %q: %w", SourceConfigBackfillEnabled, err)
+		}
+	}
+
+	if s.config.Watchlist.Path != "" {
+		if _, err := newWatchlist(s.config); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	if _, err := newPollSchedule(s.config.Poll); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if s.config.RunOnce.EndTime != "" {
+		if _, err := time.Parse(time.RFC3339, s.config.RunOnce.EndTime); err != nil {
+			return fmt.Errorf("invalid config: %q is not an RFC3339 timestamp: %w", SourceConfigRunOnceEndTime, err)
+		}
+	}
+
 	return nil
 }
 
 func (s *Source) Open(ctx context.Context, pos opencdc.Position) error {
 	sdk.Logger(ctx).Debug().Msg("Opening Source connector...")
-	c := graphql.NewClient(s.config.APIURL)
+	if s.config.ResetPosition && pos != nil {
+		sdk.Logger(ctx).Info().Str("position", string(pos)).Msg("Ignoring saved position")
+		pos = nil
+	}
+	httpClient := &http.Client{}
+	c := graphql.NewClient(s.config.APIURL, graphql.WithHTTPClient(httpClient))
 	it, err := s.iteratorCreator.NewIterator(c, s.config.Token, s.config.Query, s.config.BatchSize, pos)
 	if err != nil {
 		return fmt.Errorf("failed to create iterator: %w", err)
 	}
+	if err := it.applyConfig(s.config); err != nil {
+		return fmt.Errorf("failed to configure iterator: %w", err)
+	}
+
+	// Teardown only cleans up after a successful Open
 	s.iterator = it
+	s.httpClient = httpClient
+	s.opened = time.Now()
+	s.stop, s.stopFunc = context.WithCancel(context.Background())
 
 	if s.iterator.position != nil {
 		s.startQueryFromCursor = true
@@ -100,12 +355,36 @@ func (s *Source) Open(ctx context.Context, pos opencdc.Position) error {
 }
 
 func (s *Source) Read(ctx context.Context) (opencdc.Record, error) {
-	if !s.iterator.HasNext(ctx) && s.iterator.position != nil && !s.startQueryFromCursor {
+	s.inflight.Add(1)
+	defer s.inflight.Done()
+
+	// cancel in-flight requests when either Read's context is cancelled or
+	// the source is torn down
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(s.stop, cancel)()
+
+	if s.config.RunOnce.Enabled && s.iterator.sweeps > 0 && !s.iterator.HasNext(ctx) {
+		sdk.Logger(ctx).Info().
+			Int("nodesProcessed", s.iterator.nodesProcessed).
+			Int("nodesFiltered", s.iterator.nodesFiltered).
+			Int("nodesMalformed", s.iterator.nodesMalformed).
+			Dur("duration", time.Since(s.opened)).
+			Msg("Run once finished, all nodes read")
+		return opencdc.Record{}, ErrEndOfData
+	}
+
+	if !s.backedOff && s.iterator.schedule == nil && s.iterator.watchlist == nil && !s.iterator.HasNext(ctx) && s.iterator.position != nil && !s.startQueryFromCursor {
+		s.backedOff = true
 		return opencdc.Record{}, sdk.ErrBackoffRetry
 	}
+	s.backedOff = false
 
 	record, err := s.iterator.Next(ctx)
 	sdk.Logger(context.Background()).Info().Msgf("Nodes processed: %d", s.iterator.nodesProcessed)
+	if errors.Is(err, sdk.ErrBackoffRetry) {
+		s.backedOff = true
+	}
 	if err != nil {
 		return opencdc.Record{}, fmt.Errorf("error reading next record: %w", err)
 	}
@@ -119,16 +398,47 @@ func (s *Source) Ack(ctx context.Context, position opencdc.Position) error {
 	// outstanding acks that need to be delivered. When Teardown is called it is
 	// guaranteed there won't be any more calls to Ack.
 	// Ack can be called concurrently with Read.
-	// sdk.Logger(ctx).Debug().Str("position", string(position)).Msg("got ack")
-	return nil
+	sdk.Logger(ctx).Trace().Str("position", string(position)).Msg("got ack")
+	if s.iterator == nil {
+		return nil
+	}
+	return s.iterator.Ack(ctx, position)
 }
 
 func (s *Source) Teardown(ctx context.Context) error {
 	// Teardown signals to the plugin that there will be no more calls to any
 	// other function. After Teardown returns, the plugin should be ready for a
 	// graceful shutdown.
+	sdk.Logger(ctx).Debug().Msg("Tearing down Source connector...")
+	if s.stopFunc == nil {
+		return nil // source was never opened
+	}
+	s.stopFunc()
 
-	return nil
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, teardownTimeout)
+		defer cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(done)
+	}()
+
+	var errs []error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("timed out waiting for in-flight reads: %w", ctx.Err()))
+	}
+
+	s.httpClient.CloseIdleConnections()
+	if err := s.iterator.Flush(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to flush state: %w", err))
+	}
+	return errors.Join(errs...)
 }
 
 func (s *Source) GetConfig() SourceConfig {
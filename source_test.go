@@ -16,11 +16,24 @@ package ais
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/machinebox/graphql"
 	"github.com/matryer/is"
 	"github.com/stretchr/testify/mock"
+	"go.uber.org/goleak"
 )
 
 type MockIteratorCreator struct {
@@ -55,6 +68,19 @@ func TestSource(t *testing.T) {
 		// is.Equal(config.Token, "test-token")
 	})
 
+	t.Run("Configure_DeriveRequiresJSON", func(t *testing.T) {
+		is := is.New(t)
+		for _, encoding := range []string{PayloadEncodingProtobuf, PayloadEncodingAvro} {
+			err := NewSource().Configure(context.Background(), map[string]string{
+				"apiUrl":           "https://api.spire.com/graphql",
+				"token":            "test-token",
+				"payload.encoding": encoding,
+				"derive.geometry":  "true",
+			})
+			is.True(err != nil && strings.Contains(err.Error(), "derive.*"))
+		}
+	})
+
 	t.Run("Open", func(t *testing.T) {
 		source := NewSource()
 		cfg := map[string]string{
@@ -82,6 +108,89 @@ func TestSource(t *testing.T) {
 		mockIteratorCreator.AssertExpectations(t)
 	})
 
+	t.Run("Read_RunOnce", func(t *testing.T) {
+		is := is.New(t)
+		ctx := context.Background()
+
+		client := &MockGraphQLClient{
+			RunFn: func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+				arg := resp.(*struct{ Vessels Vessels })
+				arg.Vessels.Nodes = []Node{
+					{ID: "v1", UpdateTimestamp: "2023-11-12T21:00:00Z"},
+					{ID: "v2", UpdateTimestamp: "2023-11-13T21:00:00Z"},
+				}
+				return nil
+			},
+		}
+		it, err := NewIterator(client, "test-token", "test-query", 100, nil)
+		is.NoErr(err)
+		mockIteratorCreator := &MockIteratorCreator{}
+		mockIteratorCreator.On("NewIterator", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(it, nil).Once()
+
+		source := &Source{
+			config: SourceConfig{
+				RunOnce: RunOnceConfig{Enabled: true, EndTime: "2023-11-13T00:00:00Z"},
+			},
+			iteratorCreator: mockIteratorCreator,
+		}
+		is.NoErr(source.Open(ctx, nil))
+
+		record, err := source.Read(ctx)
+		is.NoErr(err)
+		is.Equal(record.Key, opencdc.RawData("v1"))
+
+		// v2 was updated after the end time
+		_, err = source.Read(ctx)
+		is.True(errors.Is(err, sdk.ErrBackoffRetry))
+
+		_, err = source.Read(ctx)
+		is.True(errors.Is(err, ErrEndOfData))
+		is.True(errors.Is(err, context.Canceled))
+		is.NoErr(source.Teardown(ctx))
+	})
+
+	t.Run("Read_BackoffPollsAgain", func(t *testing.T) {
+		is := is.New(t)
+		ctx := context.Background()
+
+		calls := 0
+		client := &MockGraphQLClient{
+			RunFn: func(ctx context.Context, req *graphql.Request, resp interface{}) error {
+				calls++
+				arg := resp.(*struct{ Vessels Vessels })
+				arg.Vessels.Nodes = []Node{
+					{ID: "v1", UpdateTimestamp: fmt.Sprintf("2023-11-12T21:00:%02dZ", calls)},
+				}
+				return nil
+			},
+		}
+		it, err := NewIterator(client, "test-token", "test-query", 100, nil)
+		is.NoErr(err)
+		mockIteratorCreator := &MockIteratorCreator{}
+		mockIteratorCreator.On("NewIterator", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(it, nil).Once()
+
+		source := &Source{
+			config:          SourceConfig{Poll: PollConfig{Mode: PollModeBackoff}},
+			iteratorCreator: mockIteratorCreator,
+		}
+		is.NoErr(source.Open(ctx, nil))
+
+		for i := 1; i <= 3; i++ {
+			record, err := source.Read(ctx)
+			is.NoErr(err)
+			var n Node
+			is.NoErr(json.Unmarshal(record.Payload.After.Bytes(), &n))
+			is.Equal(n.UpdateTimestamp, fmt.Sprintf("2023-11-12T21:00:%02dZ", i))
+
+			// the result set is complete, the SDK backs off before the next
+			// one is requested
+			_, err = source.Read(ctx)
+			is.True(errors.Is(err, sdk.ErrBackoffRetry))
+		}
+		is.Equal(calls, 3)
+		is.NoErr(source.Teardown(ctx))
+	})
+
 	t.Run("Ack", func(t *testing.T) {
 		source := NewSource()
 
@@ -97,4 +206,56 @@ func TestSource(t *testing.T) {
 		is := is.New(t)
 		is.NoErr(err)
 	})
+
+	t.Run("Teardown_AfterFailedOpen", func(t *testing.T) {
+		is := is.New(t)
+		source := &Source{
+			config:          SourceConfig{Config: Config{APIURL: "https://api.example.com/graphql"}},
+			iteratorCreator: SourceIteratorCreator{},
+		}
+
+		err := source.Open(context.Background(), opencdc.Position("{bad"))
+		is.True(err != nil)
+		is.NoErr(source.Teardown(context.Background()))
+	})
+
+	t.Run("Teardown_CancelsInflightRead", func(t *testing.T) {
+		is := is.New(t)
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		requested := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body)
+			close(requested)
+			<-r.Context().Done() // block until the client gives up
+		}))
+		defer server.Close()
+
+		statePath := filepath.Join(t.TempDir(), "state.json")
+		source := &Source{
+			config: SourceConfig{
+				Config:    Config{APIURL: server.URL, Token: "test-token", BatchSize: 100},
+				Query:     "test-query",
+				StatePath: statePath,
+			},
+			iteratorCreator: SourceIteratorCreator{},
+		}
+		is.NoErr(source.Open(context.Background(), nil))
+
+		readErr := make(chan error)
+		go func() {
+			_, err := source.Read(context.Background())
+			readErr <- err
+		}()
+		<-requested
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		is.NoErr(source.Teardown(ctx))
+
+		err := <-readErr
+		is.True(errors.Is(err, context.Canceled))
+		_, err = os.Stat(statePath)
+		is.NoErr(err) // state was flushed
+	})
 }
@@ -0,0 +1,130 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// vesselState is the last observed state of a single vessel.
+type vesselState struct {
+	Node     Node      `json:"node"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// vesselStore keeps the previously observed state of each vessel, keyed by the
+// Spire vessel ID.
+type vesselStore struct {
+	vessels map[string]vesselState
+}
+
+func newVesselStore() *vesselStore {
+	return &vesselStore{
+		vessels: make(map[string]vesselState),
+	}
+}
+
+// Get returns the previously stored state of the vessel with the given ID.
+func (s *vesselStore) Get(id string) (vesselState, bool) {
+	v, ok := s.vessels[id]
+	return v, ok
+}
+
+// loadVesselStore reads a vessel store previously written with Save. A missing
+// file results in an empty store.
+func loadVesselStore(path string) (*vesselStore, error) {
+	s := newVesselStore()
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading vessel state: %w", err)
+	}
+	if err := json.Unmarshal(b, &s.vessels); err != nil {
+		return nil, fmt.Errorf("error parsing vessel state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Clone returns a copy of the store.
+func (s *vesselStore) Clone() *vesselStore {
+	c := newVesselStore()
+	for id, v := range s.vessels {
+		c.vessels[id] = v
+	}
+	return c
+}
+
+// Put stores the latest observation of a vessel.
+func (s *vesselStore) Put(n Node, seen time.Time) {
+	s.vessels[n.ID] = vesselState{
+		Node:     n,
+		LastSeen: seen,
+	}
+}
+
+// Delete removes the vessel with the given ID.
+func (s *vesselStore) Delete(id string) {
+	delete(s.vessels, id)
+}
+
+// Save atomically writes the store to path.
+func (s *vesselStore) Save(path string) error {
+	b, err := json.Marshal(s.vessels)
+	if err != nil {
+		return fmt.Errorf("error occurred marshalling vessel state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("error writing vessel state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing vessel state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing vessel state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing vessel state: %w", err)
+	}
+	return nil
+}
+
+// Expire removes and returns all vessels that were last seen before the
+// cutoff, ordered by the time they were last seen.
+func (s *vesselStore) Expire(cutoff time.Time) []vesselState {
+	var expired []vesselState
+	for id, v := range s.vessels {
+		if v.LastSeen.Before(cutoff) {
+			expired = append(expired, v)
+			delete(s.vessels, id)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].LastSeen.Before(expired[j].LastSeen)
+	})
+	return expired
+}
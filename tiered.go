@@ -0,0 +1,165 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/machinebox/graphql"
+)
+
+// staticFields are the slow-changing parts of a vessel that are not polled
+// with every request in tiered polling mode.
+var staticFields = []string{"staticData", "currentVoyage"}
+
+type TieredPollingConfig struct {
+	// Enabled polls only the position updates with every request and fetches
+	// the static data and current voyage of the polled vessels on a slower
+	// schedule. The cached data is merged into every record.
+	Enabled bool `json:"enabled" default:"false"`
+	// StaticInterval is the time after which the static data of a vessel is
+	// refreshed.
+	StaticInterval time.Duration `json:"staticInterval" default:"1h"`
+}
+
+// staticQuery returns the query used to fetch the static data of the vessels
+// with the IDs in the $ids variable.
+func staticQuery(fields, excludeFields []string) (string, error) {
+	selection, err := selectionSet(fields, slices.Concat(excludeFields, []string{"lastPositionUpdate"}))
+	if err != nil {
+		return "", err
+	}
+
+	return `
+	query ($first: Int!, $after: String, $ids: [ID!]){
+	        vessels(first:$first, after:$after, ids: $ids) {
+				pageInfo {
+				 hasNextPage
+				 endCursor
+			   }
+			   nodes {
+` + selection + `			   }
+			 }
+	    }
+	`, nil
+}
+
+// staticEntry is the static data of a vessel and the time it was fetched.
+type staticEntry struct {
+	node    Node
+	fetched time.Time
+}
+
+// staticCache holds the static data and current voyage of each polled vessel.
+type staticCache struct {
+	query    string
+	interval time.Duration
+	vessels  map[string]staticEntry
+}
+
+func newStaticCache(cfg SourceConfig) (*staticCache, error) {
+	query, err := staticQuery(cfg.Fields, cfg.ExcludeFields)
+	if err != nil {
+		return nil, err
+	}
+	return &staticCache{
+		query:    query,
+		interval: cfg.TieredPolling.StaticInterval,
+		vessels:  make(map[string]staticEntry),
+	}, nil
+}
+
+// stale returns the IDs of the nodes without cached static data or with data
+// older than the interval.
+func (c *staticCache) stale(nodes []Node, now time.Time) []string {
+	var ids []string
+	for _, n := range nodes {
+		e, ok := c.vessels[n.ID]
+		if (!ok || now.Sub(e.fetched) >= c.interval) && !slices.Contains(ids, n.ID) {
+			ids = append(ids, n.ID)
+		}
+	}
+	return ids
+}
+
+// merge copies the cached static data into the node unless the node already
+// has it, e.g. if it was read by the watchlist query.
+func (c *staticCache) merge(n Node) Node {
+	cached, ok := c.vessels[n.ID]
+	if !ok {
+		return n
+	}
+	if n.StaticData == (StaticData{}) {
+		n.StaticData = cached.node.StaticData
+	}
+	if n.CurrentVoyage == (CurrentVoyage{}) {
+		n.CurrentVoyage = cached.node.CurrentVoyage
+	}
+	return n
+}
+
+// refreshStatic fetches the static data of the nodes that is missing in the
+// cache or outdated. If the request fails, the error is logged and the cached
+// data is kept, missing data is fetched again with the next page.
+func (it *Iterator) refreshStatic(ctx context.Context, nodes []Node) error {
+	if it.static == nil {
+		return nil
+	}
+	start := time.Now()
+	ids := it.static.stale(nodes, start)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	fetched := 0
+	after := ""
+	for {
+		req := graphql.NewRequest(it.static.query)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", it.token))
+		req.Var("first", it.batchSize)
+		req.Var("ids", ids)
+		if after != "" {
+			req.Var("after", after)
+		}
+
+		page, _, err := it.runQuery(ctx, req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			sdk.Logger(ctx).Err(err).Msg("failed to refresh static vessel data, keeping the cached data")
+			return nil
+		}
+		for _, n := range page.Nodes {
+			it.static.vessels[n.ID] = staticEntry{node: n, fetched: start}
+		}
+		fetched += len(page.Nodes)
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+		after = page.PageInfo.EndCursor
+	}
+
+	sdk.Logger(ctx).Debug().
+		Int("requested", len(ids)).
+		Int("vessels", fetched).
+		Dur("duration", time.Since(start)).
+		Msg("Static vessel data refreshed")
+	return nil
+}
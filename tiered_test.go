@@ -0,0 +1,90 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+)
+
+func TestIterator_TieredPolling(t *testing.T) {
+	is := is.New(t)
+
+	var staticIDs [][]string
+	positionRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string `json:"query"`
+			Variables struct {
+				IDs []string `json:"ids"`
+			} `json:"variables"`
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&req))
+
+		if !strings.Contains(req.Query, "lastPositionUpdate:") {
+			staticIDs = append(staticIDs, req.Variables.IDs)
+			is.True(!strings.Contains(req.Query, "latitude"))
+			var nodes []string
+			for _, id := range req.Variables.IDs {
+				nodes = append(nodes, fmt.Sprintf(`{"id":%q,"staticData":{"name":"Vessel %s","mmsi":123},"currentVoyage":{"destination":"Rotterdam"}}`, id, id))
+			}
+			_, _ = fmt.Fprintf(w, `{"data":{"vessels":{"nodes":[%s]}}}`, strings.Join(nodes, ","))
+			return
+		}
+		positionRequests++
+		is.True(!strings.Contains(req.Query, "destination"))
+		nodes := `{"id":"a","updateTimestamp":"2024-01-01T00:00:00Z","lastPositionUpdate":{"latitude":51.9}}`
+		if positionRequests > 1 {
+			// a vessel that was not polled before
+			nodes += `,{"id":"b","updateTimestamp":"2024-01-01T00:00:00Z","lastPositionUpdate":{"latitude":51.9}}`
+		}
+		_, _ = fmt.Fprintf(w, `{"data":{"vessels":{"nodes":[%s]}}}`, nodes)
+	}))
+	defer server.Close()
+
+	query, err := vesselQueryFields(nil, staticFields)
+	is.NoErr(err)
+	it, err := NewIterator(graphql.NewClient(server.URL), "test-token", query, 100, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{
+		TieredPolling: TieredPollingConfig{Enabled: true, StaticInterval: time.Hour},
+	}))
+
+	ctx := context.Background()
+	for _, id := range []string{"a", "a", "b"} {
+		record, err := it.Next(ctx)
+		is.NoErr(err)
+
+		var n Node
+		is.NoErr(json.Unmarshal(record.Payload.After.Bytes(), &n))
+		is.Equal(n.ID, id)
+		is.Equal(n.StaticData.Name, "Vessel "+id)
+		is.Equal(n.CurrentVoyage.Destination, "Rotterdam")
+		is.Equal(n.LastPositionUpdate.Latitude, 51.9)
+	}
+
+	// only the polled vessels are fetched, cached data is reused
+	is.Equal(staticIDs, [][]string{{"a"}, {"b"}})
+	is.Equal(positionRequests, 2)
+}
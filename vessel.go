@@ -27,7 +27,7 @@ type PageInfo struct {
 
 type TotalCount struct {
 	Value    int    `json:"value"`
-	Relation string `json:"string"`
+	Relation string `json:"relation"`
 }
 
 type Node struct {
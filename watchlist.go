@@ -0,0 +1,309 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/machinebox/graphql"
+)
+
+const (
+	// MetadataWatchlist is set to "true" on records of vessels on the
+	// watchlist.
+	MetadataWatchlist = "ais.watchlist"
+	// MetadataWatchlistLabels contains the comma separated labels of a vessel
+	// on the watchlist.
+	MetadataWatchlistLabels = "ais.watchlist.labels"
+)
+
+type WatchlistConfig struct {
+	// Path is a CSV file listing vessels that are polled more frequently.
+	// Each line contains "mmsi" or "imo", the number and optional labels.
+	// The file is reloaded when it changes.
+	Path string `json:"path"`
+	// Interval is the time between two polls of the vessels on the
+	// watchlist.
+	Interval time.Duration `json:"interval" default:"30s"`
+}
+
+// watchlist contains the vessels of interest and their labels.
+type watchlist struct {
+	path     string
+	interval time.Duration
+	query    string
+	modTime  time.Time
+	mmsi     map[int][]string
+	imo      map[int][]string
+	// next is the time the watched vessels are polled next.
+	next time.Time
+}
+
+func newWatchlist(cfg SourceConfig) (*watchlist, error) {
+	query, err := watchlistQuery(cfg.Fields, cfg.ExcludeFields)
+	if err != nil {
+		return nil, err
+	}
+	w := &watchlist{
+		path:     cfg.Watchlist.Path,
+		interval: cfg.Watchlist.Interval,
+		query:    query,
+	}
+	if _, err := w.reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// watchlistQuery returns the query used to poll the watched vessels. Either
+// the $mmsi or the $imo variable is set.
+func watchlistQuery(fields, excludeFields []string) (string, error) {
+	selection, err := selectionSet(fields, excludeFields)
+	if err != nil {
+		return "", err
+	}
+
+	return `
+	query ($first: Int!, $after: String, $mmsi: [MMSI!], $imo: [IMO!]){
+	        vessels(first:$first, after:$after, mmsi: $mmsi, imo: $imo) {
+				pageInfo {
+				 hasNextPage
+				 endCursor
+			   }
+			   nodes {
+` + selection + `			   }
+			 }
+	    }
+	`, nil
+}
+
+// reload reads the watchlist file if it was modified since it was last read
+// and reports whether it was.
+func (w *watchlist) reload() (bool, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false, fmt.Errorf("error reading watchlist: %w", err)
+	}
+	if info.ModTime().Equal(w.modTime) {
+		return false, nil
+	}
+
+	f, err := os.Open(w.path)
+	if err != nil {
+		return false, fmt.Errorf("error reading watchlist: %w", err)
+	}
+	defer f.Close()
+
+	mmsi, imo, err := parseWatchlist(f)
+	if err != nil {
+		return false, fmt.Errorf("invalid watchlist %s: %w", w.path, err)
+	}
+	w.mmsi, w.imo, w.modTime = mmsi, imo, info.ModTime()
+	return true, nil
+}
+
+// parseWatchlist parses watchlist lines like "mmsi,244660000,tanker,fleet-a".
+// Empty lines and lines starting with # are ignored.
+func parseWatchlist(r io.Reader) (mmsi, imo map[int][]string, err error) {
+	mmsi = make(map[int][]string)
+	imo = make(map[int][]string)
+
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return mmsi, imo, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rec) < 2 {
+			line, _ := cr.FieldPos(0)
+			return nil, nil, fmt.Errorf("line %d: expected an identifier type and number", line)
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(rec[1]))
+		if err != nil {
+			line, _ := cr.FieldPos(1)
+			return nil, nil, fmt.Errorf("line %d: invalid vessel number %q", line, rec[1])
+		}
+		labels := rec[2:]
+		switch strings.ToLower(strings.TrimSpace(rec[0])) {
+		case "mmsi":
+			mmsi[n] = labels
+		case "imo":
+			imo[n] = labels
+		default:
+			line, _ := cr.FieldPos(0)
+			return nil, nil, fmt.Errorf("line %d: unknown identifier type %q, expected mmsi or imo", line, rec[0])
+		}
+	}
+}
+
+// labels returns the labels of the vessel and whether it is on the watchlist.
+func (w *watchlist) labels(n Node) ([]string, bool) {
+	if labels, ok := w.mmsi[n.StaticData.MMSI]; ok && n.StaticData.MMSI != 0 {
+		return labels, true
+	}
+	if labels, ok := w.imo[n.StaticData.IMO]; ok && n.StaticData.IMO != 0 {
+		return labels, true
+	}
+	return nil, false
+}
+
+// annotate adds the watchlist metadata to records of watched vessels.
+func (w *watchlist) annotate(n Node, metadata opencdc.Metadata) {
+	labels, ok := w.labels(n)
+	if !ok {
+		return
+	}
+	metadata[MetadataWatchlist] = "true"
+	if len(labels) > 0 {
+		metadata[MetadataWatchlistLabels] = strings.Join(labels, ",")
+	}
+}
+
+// watchedNode is a node of a watched vessel and the page it was read from.
+type watchedNode struct {
+	node Node
+	page fetchedPage
+}
+
+// pollWatchlist fetches the watched vessels if the poll is due. The nodes are
+// emitted before any further nodes of the regular result set, nodes that were
+// not updated since they were last emitted are skipped.
+func (it *Iterator) pollWatchlist(ctx context.Context) error {
+	if it.watchlist == nil || time.Now().Before(it.watchlist.next) {
+		return nil
+	}
+
+	if reloaded, err := it.watchlist.reload(); err != nil {
+		sdk.Logger(ctx).Err(err).Msg("failed to reload watchlist, keeping the current watchlist")
+	} else if reloaded {
+		sdk.Logger(ctx).Info().
+			Int("mmsi", len(it.watchlist.mmsi)).
+			Int("imo", len(it.watchlist.imo)).
+			Msg("Watchlist reloaded")
+	}
+
+	for _, v := range []struct {
+		name    string
+		numbers map[int][]string
+	}{{"mmsi", it.watchlist.mmsi}, {"imo", it.watchlist.imo}} {
+		if len(v.numbers) == 0 {
+			continue
+		}
+		numbers := slices.Sorted(maps.Keys(v.numbers))
+
+		after, number := "", 0
+		for {
+			req := graphql.NewRequest(it.watchlist.query)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", it.token))
+			req.Var("first", it.batchSize)
+			req.Var(v.name, numbers)
+			if after != "" {
+				req.Var("after", after)
+			}
+
+			page, fetched, err := it.runQuery(ctx, req)
+			if err != nil {
+				return fmt.Errorf("error polling watchlist: %w", err)
+			}
+			number++
+			fetched.query = watchlistQueryName
+			fetched.cursor = after
+			fetched.number = number
+			for _, n := range page.Nodes {
+				if it.static != nil {
+					n = it.static.merge(n)
+				}
+				if !it.watchedUpdate(n) {
+					continue
+				}
+				if !it.accept(ctx, n) {
+					it.nodesFiltered++
+					continue
+				}
+				it.watched = append(it.watched, watchedNode{node: n, page: fetched})
+			}
+			if !page.PageInfo.HasNextPage {
+				break
+			}
+			after = page.PageInfo.EndCursor
+		}
+	}
+
+	it.watchlist.next = time.Now().Add(it.watchlist.interval)
+	return nil
+}
+
+// watchedUpdate reports whether the node of a watched vessel has a different
+// update timestamp than the last emitted or queued node of the vessel.
+func (it *Iterator) watchedUpdate(n Node) bool {
+	if prev, ok := it.store.Get(n.ID); ok && prev.Node.UpdateTimestamp == n.UpdateTimestamp {
+		return false
+	}
+	return !slices.ContainsFunc(it.watched, func(w watchedNode) bool {
+		return w.node.ID == n.ID && w.node.UpdateTimestamp == n.UpdateTimestamp
+	})
+}
+
+// nextWatched returns the record of the next watched vessel. Its position is
+// the one of the preceding record with its own watched sequence number, so
+// resuming continues the regular result set. It reports false if the node
+// was malformed and skipped.
+func (it *Iterator) nextWatched(ctx context.Context) (opencdc.Record, bool, error) {
+	var w watchedNode
+	w, it.watched = it.watched[0], it.watched[1:]
+
+	position := Position{
+		Offset:    -1,
+		Watermark: it.watermark,
+		StartTime: it.startTime,
+		WindowEnd: it.windowEnd,
+	}
+	if it.position != nil {
+		var err error
+		if position, err = ParsePosition(it.position); err != nil {
+			return opencdc.Record{}, false, err
+		}
+	}
+	it.watchedSeq++
+	position.Watched = it.watchedSeq
+	it.position = position.ToRecordPosition()
+	record, err := it.wrap(w.node, w.page)
+	if err != nil {
+		return it.malformed(ctx, w.node, w.page, err)
+	}
+	if err := it.trackChanges(w.node, record.Metadata); err != nil {
+		return opencdc.Record{}, false, err
+	}
+	return record, true, nil
+}
@@ -0,0 +1,165 @@
+// Copyright © 2023 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ais
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/machinebox/graphql"
+	"github.com/matryer/is"
+)
+
+func TestParseWatchlist(t *testing.T) {
+	is := is.New(t)
+
+	mmsi, imo, err := parseWatchlist(strings.NewReader(`# vessels of interest
+mmsi,244660000,tanker,fleet-a
+IMO, 9321483
+
+mmsi,211331640
+`))
+	is.NoErr(err)
+	is.Equal(mmsi, map[int][]string{244660000: {"tanker", "fleet-a"}, 211331640: {}})
+	is.Equal(imo, map[int][]string{9321483: {}})
+
+	_, _, err = parseWatchlist(strings.NewReader("callsign,PBIG"))
+	is.True(err != nil)
+	_, _, err = parseWatchlist(strings.NewReader("mmsi,unknown"))
+	is.True(err != nil)
+}
+
+func TestIterator_Watchlist(t *testing.T) {
+	is := is.New(t)
+
+	path := filepath.Join(t.TempDir(), "watchlist.csv")
+	is.NoErr(os.WriteFile(path, []byte("mmsi,1,tanker\n"), 0o600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables struct {
+				MMSI []int `json:"mmsi"`
+			} `json:"variables"`
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&req))
+
+		if req.Variables.MMSI != nil {
+			is.Equal(req.Variables.MMSI, []int{1})
+			_, _ = w.Write([]byte(`{"data":{"vessels":{"nodes":[{"id":"a","updateTimestamp":"2024-01-01T00:00:00Z","staticData":{"mmsi":1}}]}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"vessels":{"nodes":[` +
+			`{"id":"a","updateTimestamp":"2024-01-01T00:00:00Z","staticData":{"mmsi":1}},` +
+			`{"id":"b","updateTimestamp":"2024-01-01T00:00:00Z","staticData":{"mmsi":2}}]}}}`))
+	}))
+	defer server.Close()
+
+	it, err := NewIterator(graphql.NewClient(server.URL), "test-token", vesselQuery(), 100, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{
+		Watchlist: WatchlistConfig{Path: path, Interval: time.Hour},
+	}))
+
+	ctx := context.Background()
+	var got []string
+	for i := 0; i < 3; i++ {
+		record, err := it.Next(ctx)
+		is.NoErr(err)
+		got = append(got, string(record.Key.Bytes())+":"+record.Metadata[MetadataWatchlistLabels])
+	}
+
+	// the watched vessel is polled first and labelled in the regular sweep
+	is.Equal(got, []string{"a:tanker", "a:tanker", "b:"})
+
+	// the watched vessel was not updated since it was emitted
+	it.watchlist.next = time.Time{}
+	is.NoErr(it.pollWatchlist(ctx))
+	is.Equal(len(it.watched), 0)
+}
+
+func TestIterator_WatchlistPositions(t *testing.T) {
+	is := is.New(t)
+
+	path := filepath.Join(t.TempDir(), "watchlist.csv")
+	is.NoErr(os.WriteFile(path, []byte("mmsi,1\nmmsi,2\n"), 0o600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables struct {
+				MMSI []int `json:"mmsi"`
+			} `json:"variables"`
+		}
+		is.NoErr(json.NewDecoder(r.Body).Decode(&req))
+
+		if req.Variables.MMSI != nil {
+			_, _ = w.Write([]byte(`{"data":{"vessels":{"nodes":[` +
+				`{"id":"a","updateTimestamp":"2024-01-01T00:00:00Z","staticData":{"mmsi":1}},` +
+				`{"id":"b","updateTimestamp":"2024-01-01T00:00:00Z","staticData":{"mmsi":2}}]}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"vessels":{"nodes":[` +
+			`{"id":"c","updateTimestamp":"2024-01-01T00:00:00Z","staticData":{"mmsi":3}},` +
+			`{"id":"d","updateTimestamp":"2024-01-01T00:00:00Z","staticData":{"mmsi":4}}]}}}`))
+	}))
+	defer server.Close()
+
+	it, err := NewIterator(graphql.NewClient(server.URL), "test-token", vesselQuery(), 100, nil)
+	is.NoErr(err)
+	is.NoErr(it.applyConfig(SourceConfig{
+		Watchlist: WatchlistConfig{Path: path, Interval: time.Hour},
+	}))
+
+	ctx := context.Background()
+	var keys []string
+	var positions []opencdc.Position
+	for i := 0; i < 3; i++ {
+		record, err := it.Next(ctx)
+		is.NoErr(err)
+		keys = append(keys, string(record.Key.Bytes()))
+		positions = append(positions, record.Position)
+	}
+	// a watched vessel between two regular records
+	it.watched = append(it.watched, watchedNode{node: Node{ID: "e", UpdateTimestamp: "2024-01-01T00:00:00Z"}})
+	for i := 0; i < 2; i++ {
+		record, err := it.Next(ctx)
+		is.NoErr(err)
+		keys = append(keys, string(record.Key.Bytes()))
+		positions = append(positions, record.Position)
+	}
+	is.Equal(keys, []string{"a", "b", "c", "e", "d"})
+
+	for i, p := range positions {
+		for _, other := range positions[:i] {
+			is.True(!bytes.Equal(p, other)) // every record has its own position
+		}
+	}
+	for _, p := range positions {
+		_, err := it.checkpoints.Ack(p)
+		is.NoErr(err)
+	}
+	is.Equal(it.checkpoints.Inflight(), 0)
+	committed, err := ParsePosition(positions[len(positions)-1])
+	is.NoErr(err)
+	is.Equal(it.checkpoints.Committed(), committed)
+}